@@ -14,6 +14,16 @@ type Header struct {
 	PrevBlockHash types.Hash
 	Timestamp     int64
 	Height        uint32
+	// BaseFee 是该区块中每单位 gas 必须支付并被销毁的基础费用
+	BaseFee uint64
+	// GasUsed 是该区块中所有交易消耗的 gas 之和
+	GasUsed uint64
+	// StateRoot 是应用该区块中的交易和区块奖励后的状态根
+	StateRoot types.Hash
+	// ReceiptsRoot 是该区块中所有交易收据的根哈希，见 ReceiptsRoot
+	ReceiptsRoot types.Hash
+	// LogsBloom 是该区块中所有事件的合约地址和主题组成的布隆过滤器，用于快速跳过不含所需事件的区块
+	LogsBloom Bloom
 }
 
 func (h *Header) Bytes() []byte {
@@ -71,14 +81,14 @@ func (b *Block) Hash(hasher Hasher[*Header]) types.Hash {
 	return b.hash
 }
 
-// Sign 对区块进行签名。
+// Sign 对区块头的哈希进行签名，签名覆盖区块头的所有字段。
 // 参数:
 // - privateKey: 执行签名的私钥。
 // 返回值:
 // - error: 执行过程中遇到的错误。
 func (b *Block) Sign(privateKey crypto.PrivateKey) error {
-	// 使用私钥对区块头数据进行签名
-	sign, err := privateKey.Sign(b.Header.Bytes())
+	// 对区块头的哈希签名。P-256 只使用输入的前 32 字节，直接对 gob 编码签名只会覆盖其中恒定的类型描述
+	sign, err := privateKey.Sign(BlockHasher{}.Hash(b.Header).ToSlice())
 	if err != nil {
 		return err // 如果签名过程中出现错误，则返回错误
 	}
@@ -94,14 +104,29 @@ func (b *Block) Verify() error {
 	if b.Signature == nil {
 		return fmt.Errorf("block has no signature")
 	}
-	if !b.Signature.Verify(b.Validator, b.Header.Bytes()) {
+	if !b.Signature.Verify(b.Validator, BlockHasher{}.Hash(b.Header).ToSlice()) {
 		return fmt.Errorf("invalid signature")
 	}
 
-	for _, tx := range b.Transactions {
-		if err := tx.Verify(); err != nil {
-			return err
-		}
+	if idx, err := b.VerifyTransactions(); err != nil {
+		return fmt.Errorf("invalid transaction at index %d: %w", idx, err)
 	}
 	return nil
 }
+
+// VerifyTransactions 使用与 CPU 核数相同数量的协程并发验证区块中的所有交易。
+//
+// 返回值:
+//
+//	int - 第一个验证失败的交易下标，全部通过时为 -1。
+//	error - 该交易的验证错误。
+func (b *Block) VerifyTransactions() (int, error) {
+	return b.verifyTransactions(0)
+}
+
+func (b *Block) verifyTransactions(workers int) (int, error) {
+	// 每个协程只访问自己下标的交易，Verify 缓存发送者地址时不存在竞争
+	return crypto.RunBatch(len(b.Transactions), workers, func(i int) error {
+		return b.Transactions[i].Verify()
+	})
+}
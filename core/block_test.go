@@ -44,3 +44,90 @@ func TestBlock_Sign(t *testing.T) {
 	block.Validator = otherPrivKey.PublicKey()
 	assert.NotNil(t, block.Verify())
 }
+
+func TestBlock_Sign_CoversHeader(t *testing.T) {
+	tamper := map[string]func(h *Header){
+		"Version":       func(h *Header) { h.Version++ },
+		"DataHash":      func(h *Header) { h.DataHash = types.RandomHash() },
+		"PrevBlockHash": func(h *Header) { h.PrevBlockHash = types.RandomHash() },
+		"Timestamp":     func(h *Header) { h.Timestamp++ },
+		"Height":        func(h *Header) { h.Height++ },
+		"BaseFee":       func(h *Header) { h.BaseFee++ },
+		"GasUsed":       func(h *Header) { h.GasUsed++ },
+		"StateRoot":     func(h *Header) { h.StateRoot = types.RandomHash() },
+		"ReceiptsRoot":  func(h *Header) { h.ReceiptsRoot = types.RandomHash() },
+		"LogsBloom":     func(h *Header) { h.LogsBloom.Add([]byte("log")) },
+	}
+	for name, modify := range tamper {
+		for _, algo := range []crypto.Algorithm{crypto.AlgoP256, crypto.AlgoEd25519} {
+			privateKey, err := crypto.GenerateKey(algo)
+			assert.Nil(t, err)
+			block := randomBlock(1, types.RandomHash())
+			assert.Nil(t, block.Sign(privateKey))
+			assert.Nil(t, block.Verify())
+			modify(block.Header)
+			assert.NotNil(t, block.Verify(), "tampered %s with algorithm %d", name, algo)
+		}
+	}
+}
+
+func TestBlock_Sign_Ed25519(t *testing.T) {
+	privateKey, err := crypto.GenerateKey(crypto.AlgoEd25519)
+	assert.Nil(t, err)
+	block := randomBlock(0, types.Hash{})
+
+	assert.Nil(t, block.Sign(privateKey))
+	assert.Equal(t, crypto.AlgoEd25519, block.Signature.Algo)
+	assert.Nil(t, block.Verify())
+
+	// 验证者公钥与签名算法不一致时验证失败
+	block.Validator = crypto.GeneratePrivateKey().PublicKey()
+	assert.NotNil(t, block.Verify())
+}
+
+func TestBlock_VerifyTransactions(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	block := randomBlock(0, types.Hash{})
+	for i := 0; i < 32; i++ {
+		block.AddTransaction(randomTxWithSignature(t))
+	}
+	assert.Nil(t, block.Sign(privateKey))
+	assert.Nil(t, block.Verify())
+
+	idx, err := block.VerifyTransactions()
+	assert.Nil(t, err)
+	assert.Equal(t, -1, idx)
+	for i := range block.Transactions {
+		assert.NotEqual(t, types.Address{}, block.Transactions[i].From())
+	}
+
+	block.Transactions[20].Signature = nil
+	block.Transactions[9].Signature = nil
+	idx, err = block.VerifyTransactions()
+	assert.NotNil(t, err)
+	assert.Equal(t, 9, idx)
+	assert.NotNil(t, block.Verify())
+}
+
+func BenchmarkBlock_VerifyTransactions(b *testing.B) {
+	block := randomBlock(0, types.Hash{})
+	for i := 0; i < 512; i++ {
+		privateKey := crypto.GeneratePrivateKey()
+		tx := NewTransaction([]byte(fmt.Sprintf("tx-%d", i)))
+		if err := tx.Sign(privateKey); err != nil {
+			b.Fatal(err)
+		}
+		block.AddTransaction(tx)
+	}
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			block.verifyTransactions(1)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			block.verifyTransactions(0)
+		}
+	})
+}
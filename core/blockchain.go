@@ -1,16 +1,30 @@
 package core
 
 import (
+	"MyChain/types"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"math"
 	"sync"
 )
 
+// DefaultStateHistory 是新区块链默认保留的最近区块的世界状态数量
+const DefaultStateHistory = 128
+
 type Blockchain struct {
-	store     Storage
-	lock      sync.RWMutex
-	headers   []*Header
-	validator Validator
+	store        Storage
+	lock         sync.RWMutex
+	headers      []*Header
+	supply       []uint64     // 每个高度的货币总供应量，下标为区块高度
+	receipts     [][]*Receipt // 每个高度的区块中交易的收据，下标为区块高度
+	states       []*State     // 最近 stateHistory 个区块被添加后的世界状态，下标为区块高度减去 stateBase，添加后不再修改
+	stateBase    uint32       // states 中最早的状态所在的区块高度，更早的状态已被丢弃
+	stateHistory int
+	txLookup     map[types.Hash]txLocation
+	validator    Validator
+	issuance     IssuanceSchedule
+	feeMarket    FeeMarket
+	gasLimit     uint64
 }
 
 // NewBlockChain 创建一个新的区块链实例。
@@ -24,13 +38,40 @@ type Blockchain struct {
 //	*Blockchain: 初始化后的区块链实例。
 //	error: 如果在初始化过程中遇到错误，则返回错误信息；否则返回nil。
 func NewBlockChain(genesis *Block) (*Blockchain, error) {
+	return NewBlockChainWithState(genesis, NewState())
+}
+
+// NewBlockChainWithState 使用创世区块和创世状态（如初始账户余额）创建一个新的区块链实例。
+// 创世区块中的交易不会被应用到状态上，创世状态中的余额总和即为高度 0 的总供应量。
+// 新的区块链不发行区块奖励，基础费用按 DefaultFeeMarket 调整，区块 gas 上限为 DefaultBlockGasLimit，
+// 保留最近 DefaultStateHistory 个区块的世界状态，可以通过 SetIssuanceSchedule、SetFeeMarket、
+// SetBlockGasLimit 和 SetStateHistory 修改。
+//
+// 参数:
+//
+//	genesis *Block: 用于初始化区块链的创世区块。
+//	state *State: 创世状态。
+//
+// 返回值:
+//
+//	*Blockchain: 初始化后的区块链实例。
+//	error: 如果在初始化过程中遇到错误，则返回错误信息；否则返回nil。
+func NewBlockChainWithState(genesis *Block, state *State) (*Blockchain, error) {
 	// 初始化Blockchain结构体，包括空的区块头切片和一个新的内存存储实例
 	bc := &Blockchain{
-		headers: []*Header{},
-		store:   NewMemoryStorage(),
+		headers:      []*Header{},
+		store:        NewMemoryStorage(),
+		txLookup:     make(map[types.Hash]txLocation),
+		gasLimit:     DefaultBlockGasLimit,
+		feeMarket:    DefaultFeeMarket,
+		stateHistory: DefaultStateHistory,
 	}
-	// 尝试添加创世区块，不进行验证
-	err := bc.addBlockWithoutValidation(genesis)
+	supply, err := state.TotalBalance()
+	if err != nil {
+		return nil, err
+	}
+	// 尝试添加创世区块，不进行验证，创世区块中的交易没有收据
+	err = bc.addBlockWithoutValidation(genesis, &BlockResult{State: state, Supply: supply})
 	if err != nil {
 		return nil, err // 如果添加创世区块失败，则返回错误
 	}
@@ -44,14 +85,24 @@ func NewBlockChain(genesis *Block) (*Blockchain, error) {
 // 参数:
 //
 //	b *Block - 需要被添加到区块链的区块。
+//	result *BlockResult - 应用该区块的结果，包括应用后的状态、货币总供应量和交易收据。
 //
 // 返回值:
 //
 //	error - 添加过程中遇到的错误，如果没有错误则为 nil。
-func (bc *Blockchain) addBlockWithoutValidation(b *Block) error {
+func (bc *Blockchain) addBlockWithoutValidation(b *Block, result *BlockResult) error {
 	bc.lock.Lock()
-	// 将新区块的头添加到区块链的头列表中
+	// 将新区块的头添加到区块链的头列表中，并切换到应用该区块后的状态
 	bc.headers = append(bc.headers, b.Header)
+	bc.supply = append(bc.supply, result.Supply)
+	bc.receipts = append(bc.receipts, result.Receipts)
+	for _, receipt := range result.Receipts {
+		bc.txLookup[receipt.TxHash] = txLocation{height: receipt.BlockHeight, index: receipt.TxIndex}
+	}
+	// 已添加的状态不会再被撤销，丢弃修改日志以释放内存
+	result.State.clearJournal()
+	bc.states = append(bc.states, result.State)
+	bc.pruneStates()
 	bc.lock.Unlock()
 
 	logrus.WithFields(logrus.Fields{
@@ -66,6 +117,63 @@ func (bc *Blockchain) SetValidator(v Validator) {
 	bc.validator = v
 }
 
+// SetIssuanceSchedule 设置此后添加的区块所使用的发行计划。
+func (bc *Blockchain) SetIssuanceSchedule(s IssuanceSchedule) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	bc.issuance = s
+}
+
+// SetFeeMarket 设置此后添加的区块所使用的基础费用调整规则。
+func (bc *Blockchain) SetFeeMarket(m FeeMarket) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	bc.feeMarket = m
+}
+
+// NextBaseFee 返回下一个区块必须使用的基础费用，由当前区块的基础费用和消耗的 gas 决定。
+func (bc *Blockchain) NextBaseFee() uint64 {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	head := bc.headers[len(bc.headers)-1]
+	return bc.feeMarket.NextBaseFee(head.BaseFee, head.GasUsed)
+}
+
+// SetBlockGasLimit 设置此后添加的区块中所有交易 GasLimit 之和的上限。
+func (bc *Blockchain) SetBlockGasLimit(limit uint64) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	bc.gasLimit = limit
+}
+
+// SetStateHistory 设置保留世界状态的最近区块数量，更早的状态被丢弃，StateAt 和 SimulateAt 不能再访问。
+// n 小于 1 时按 1 处理，即只保留当前高度的状态。
+func (bc *Blockchain) SetStateHistory(n int) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	bc.stateHistory = max(n, 1)
+	bc.pruneStates()
+}
+
+// pruneStates 丢弃超出保留数量的最早的世界状态，调用方需持有写锁。
+func (bc *Blockchain) pruneStates() {
+	n := len(bc.states) - bc.stateHistory
+	if n <= 0 {
+		return
+	}
+	// 清空被丢弃的元素，使底层数组不再引用这些状态
+	clear(bc.states[:n])
+	bc.states = bc.states[n:]
+	bc.stateBase += uint32(n)
+}
+
+// BlockGasLimit 返回区块中所有交易 GasLimit 之和的上限。
+func (bc *Blockchain) BlockGasLimit() uint64 {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.gasLimit
+}
+
 // HasBlock 检查区块链中是否存在指定高度的区块
 // 参数：
 //
@@ -80,8 +188,9 @@ func (bc *Blockchain) HasBlock(height uint32) bool {
 }
 
 // AddBlock 将一个新的区块添加到区块链中。
-// 此函数首先会验证区块的有效性，如果验证失败，则返回相应的错误。
-// 如果验证成功，则会调用内部函数 addBlockWithoutValidation 来实际添加区块。
+// 此函数首先会验证区块的有效性，然后在当前状态的拷贝上依次应用区块中的交易并向验证者发放区块奖励，
+// 如果验证失败、交易无法应用（如透支）或应用结果与区块头不符，则返回相应的错误，状态保持不变。
+// 如果全部成功，则会调用内部函数 addBlockWithoutValidation 来实际添加区块。
 //
 // 参数:
 //
@@ -96,7 +205,195 @@ func (bc *Blockchain) AddBlock(b *Block) error {
 	if err != nil {
 		return err // 验证失败，返回错误
 	}
-	return bc.addBlockWithoutValidation(b) // 验证成功，添加区块
+	result, err := bc.ExecuteBlock(b)
+	if err != nil {
+		return err // 交易无法应用，拒绝该区块
+	}
+	// 验证应用区块后的结果与区块头中的承诺一致
+	if err := bc.validator.ValidateState(b, result); err != nil {
+		return err
+	}
+	return bc.addBlockWithoutValidation(b, result) // 验证成功，添加区块
+}
+
+// BlockResult 是在链尾应用一个区块的结果。
+type BlockResult struct {
+	// State 是应用该区块后的状态
+	State *State
+	// GasUsed 是区块中所有交易消耗的 gas 之和
+	GasUsed uint64
+	// Supply 是扣除销毁的基础费用、加上区块奖励后的货币总供应量
+	Supply uint64
+	// Receipts 是区块中每笔交易的收据，顺序与交易相同
+	Receipts []*Receipt
+}
+
+// ExecuteBlock 在当前状态的拷贝上依次应用区块中的交易，然后向验证者发放区块奖励。
+// 区块本身不会被验证，也不会被添加到区块链中，出块者可以用它在签名前填写区块头的 StateRoot、ReceiptsRoot、LogsBloom 和 GasUsed。
+// 区块的 Validator 必须已设置为出块者的公钥，因为小费和区块奖励支付给该地址。
+//
+// 参数:
+//
+//	b *Block - 接在当前链尾的区块。
+//
+// 返回值:
+//
+//	*BlockResult - 应用该区块的结果。
+//	error - 区块中的交易无法应用时返回错误。
+func (bc *Blockchain) ExecuteBlock(b *Block) (*BlockResult, error) {
+	bc.lock.RLock()
+	result := &BlockResult{
+		State:  bc.headState().Copy(),
+		Supply: bc.supply[len(bc.supply)-1],
+	}
+	reward := bc.issuance.RewardAt(b.Height)
+	bc.lock.RUnlock()
+
+	ctx := NewBlockContext(b)
+	for i := range b.Transactions {
+		res, err := result.State.ApplyTransaction(ctx, &b.Transactions[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply transaction at index %d: %w", i, err)
+		}
+		if result.GasUsed > math.MaxUint64-res.GasUsed {
+			return nil, fmt.Errorf("block gas used overflows at transaction index %d", i)
+		}
+		result.GasUsed += res.GasUsed
+		result.Receipts = append(result.Receipts, NewReceipt(&b.Transactions[i], res, b.Height, uint32(i)))
+		// 每笔交易销毁的基础费用都来自发送者已有的余额，因此不会超过总供应量
+		result.Supply -= res.GasUsed * ctx.BaseFee
+	}
+	if reward > 0 {
+		if result.Supply > math.MaxUint64-reward {
+			return nil, fmt.Errorf("total supply overflow at height %d", b.Height)
+		}
+		if err := result.State.AddBalance(ctx.Validator, reward); err != nil {
+			return nil, fmt.Errorf("failed to pay block reward: %w", err)
+		}
+		result.Supply += reward
+	}
+	return result, nil
+}
+
+// txLocation 是交易在区块链中的位置。
+type txLocation struct {
+	height uint32
+	index  uint32
+}
+
+// GetReceipt 返回已打包的交易的收据。
+//
+// 参数:
+//
+//	hash - 交易哈希。
+//
+// 返回值:
+//
+//	*Receipt - 交易的收据。
+//	error - 交易不在区块链中时返回错误。
+func (bc *Blockchain) GetReceipt(hash types.Hash) (*Receipt, error) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	loc, ok := bc.txLookup[hash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", hash)
+	}
+	return bc.receipts[loc.height][loc.index], nil
+}
+
+// GetReceipts 返回指定高度的区块中所有交易的收据，顺序与交易相同。
+func (bc *Blockchain) GetReceipts(height uint32) ([]*Receipt, error) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	if int(height) >= len(bc.receipts) {
+		return nil, fmt.Errorf("blockchain height is %d, but get %d", len(bc.receipts)-1, height)
+	}
+	return bc.receipts[height], nil
+}
+
+// ProveAccount 生成当前状态下地址对应账户的证明，可以针对当前区块头的 StateRoot 验证。
+func (bc *Blockchain) ProveAccount(addr types.Address) *AccountProof {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.headState().Prove(addr)
+}
+
+// State 返回当前世界状态的拷贝。
+func (bc *Blockchain) State() *State {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.headState().Copy()
+}
+
+// StateAt 返回指定高度的区块被添加后的世界状态的拷贝。
+//
+// 参数:
+//
+//	height - 区块高度。
+//
+// 返回值:
+//
+//	*State - 该高度的世界状态。
+//	error - 高度超过当前区块链高度，或该高度的状态已超出保留范围被丢弃时返回错误。
+func (bc *Blockchain) StateAt(height uint32) (*State, error) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	if int(height) >= len(bc.headers) {
+		return nil, fmt.Errorf("blockchain height is %d, but get %d", len(bc.headers)-1, height)
+	}
+	if height < bc.stateBase {
+		return nil, fmt.Errorf("state at height %d has been pruned, the oldest available is %d", height, bc.stateBase)
+	}
+	return bc.states[height-bc.stateBase].Copy(), nil
+}
+
+// headState 返回当前高度的世界状态，调用方需持有读锁且不能修改返回的状态。
+func (bc *Blockchain) headState() *State {
+	return bc.states[len(bc.states)-1]
+}
+
+// GetAccount 返回当前状态下地址对应的账户。
+func (bc *Blockchain) GetAccount(addr types.Address) Account {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.headState().GetAccount(addr)
+}
+
+// BalanceOf 返回当前状态下地址的余额。
+func (bc *Blockchain) BalanceOf(addr types.Address) uint64 {
+	return bc.GetAccount(addr).Balance
+}
+
+// NonceOf 返回当前状态下地址的 nonce，即该地址下一笔交易应使用的 Nonce。
+func (bc *Blockchain) NonceOf(addr types.Address) uint64 {
+	return bc.GetAccount(addr).Nonce
+}
+
+// TotalSupply 返回当前高度的货币总供应量。
+func (bc *Blockchain) TotalSupply() uint64 {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.supply[len(bc.supply)-1]
+}
+
+// TotalSupplyAt 返回指定高度的区块被添加后的货币总供应量，即创世余额加上截至该高度所有区块奖励，
+// 再减去截至该高度销毁的基础费用。
+//
+// 参数:
+//
+//	height - 区块高度。
+//
+// 返回值:
+//
+//	uint64 - 该高度的总供应量。
+//	error - 高度超过当前区块链高度时返回错误。
+func (bc *Blockchain) TotalSupplyAt(height uint32) (uint64, error) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	if int(height) >= len(bc.supply) {
+		return 0, fmt.Errorf("blockchain height is %d, but get %d", len(bc.supply)-1, height)
+	}
+	return bc.supply[height], nil
 }
 
 // Height 返回Blockchain当前的高度，即区块头的数量减一。
@@ -1,15 +1,19 @@
 package core
 
 import (
+	"MyChain/crypto"
 	"MyChain/types"
+	"MyChain/vm"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
+// newBlockChainWithGenesis 创建基础费用固定为 0 的区块链，测试中的交易不需要支付手续费。
 func newBlockChainWithGenesis(t *testing.T) *Blockchain {
 	bc, err := NewBlockChain(randomBlock(0, types.Hash{}))
 	assert.Nil(t, err)
+	bc.SetFeeMarket(FeeMarket{})
 	return bc
 }
 
@@ -23,6 +27,12 @@ func TestNewBlockChain(t *testing.T) {
 	bc := newBlockChainWithGenesis(t)
 	assert.NotNil(t, bc.validator)
 	fmt.Println(bc.Height())
+
+	// 默认使用动态的基础费用
+	bc, err := NewBlockChain(randomBlock(0, types.Hash{}))
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultFeeMarket, bc.feeMarket)
+	assert.Equal(t, DefaultFeeMarket.MinBaseFee, bc.NextBaseFee())
 }
 func TestBlockchain_HasBlock(t *testing.T) {
 	bc := newBlockChainWithGenesis(t)
@@ -32,7 +42,7 @@ func TestBlockchain_AddBlock(t *testing.T) {
 	bc := newBlockChainWithGenesis(t)
 	lenBlock := 1000
 	for i := 0; i < lenBlock; i++ {
-		block := randomBlockWithSignature(t, uint32(i+1), getPrevBlockHash(t, uint32(i+1), bc))
+		block := nextBlock(t, bc, randomTxWithSignature(t))
 		assert.Nil(t, bc.AddBlock(block))
 	}
 	assert.Equal(t, uint32(lenBlock), bc.Height())
@@ -50,10 +60,306 @@ func TestBlockchain_GetHeader(t *testing.T) {
 	bc := newBlockChainWithGenesis(t)
 	lenBlock := 1000
 	for i := 0; i < lenBlock; i++ {
-		block := randomBlockWithSignature(t, uint32(i+1), getPrevBlockHash(t, uint32(i+1), bc))
+		block := nextBlock(t, bc, randomTxWithSignature(t))
 		assert.Nil(t, bc.AddBlock(block))
 		header, err := bc.GetHeader(uint32(i + 1))
 		assert.Nil(t, err)
 		assert.Equal(t, block.Header, header)
 	}
 }
+
+func newBlockChainWithBalances(t *testing.T, balances map[types.Address]uint64) *Blockchain {
+	state := NewState()
+	for addr, balance := range balances {
+		assert.Nil(t, state.AddBalance(addr, balance))
+	}
+	bc, err := NewBlockChainWithState(randomBlock(0, types.Hash{}), state)
+	assert.Nil(t, err)
+	bc.SetFeeMarket(FeeMarket{})
+	return bc
+}
+
+// nextBlock 创建一个包含给定交易、接在当前链尾部的已签名区块。
+func nextBlock(t *testing.T, bc *Blockchain, txs ...*Transaction) *Block {
+	return nextBlockWithValidator(t, bc, crypto.GeneratePrivateKey(), txs...)
+}
+
+// nextBlockWithValidator 与 nextBlock 相同，但由给定的验证者签名，并填写基础费用、状态根、收据根、事件布隆过滤器和消耗的 gas。
+func nextBlockWithValidator(t *testing.T, bc *Blockchain, validator crypto.PrivateKey, txs ...*Transaction) *Block {
+	height := bc.Height() + 1
+	b := randomBlock(height, getPrevBlockHash(t, height, bc))
+	b.BaseFee = bc.NextBaseFee()
+	for _, tx := range txs {
+		b.AddTransaction(tx)
+	}
+	// 交易无法应用时区块本身就是无效的，状态根保持为零
+	b.Validator = validator.PublicKey()
+	if result, err := bc.ExecuteBlock(b); err == nil {
+		b.StateRoot = result.State.Root()
+		b.ReceiptsRoot = ReceiptsRoot(result.Receipts)
+		b.LogsBloom = CreateBloom(result.Receipts)
+		b.GasUsed = result.GasUsed
+	}
+	assert.Nil(t, b.Sign(validator))
+	return b
+}
+
+func TestBlockchain_AddBlock_Transfer(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc,
+		signedTransfer(t, alice, 0, bob, 30),
+		signedTransfer(t, alice, 1, bob, 20),
+	)))
+	assert.Equal(t, uint64(50), bc.BalanceOf(aliceAddr))
+	assert.Equal(t, uint64(50), bc.BalanceOf(bob))
+	assert.Equal(t, uint64(2), bc.NonceOf(aliceAddr))
+}
+
+func TestBlockchain_AddBlock_Overdraft(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+
+	// 第二笔交易透支，整个区块被拒绝，第一笔交易也不生效
+	assert.NotNil(t, bc.AddBlock(nextBlock(t, bc,
+		signedTransfer(t, alice, 0, bob, 60),
+		signedTransfer(t, alice, 1, bob, 60),
+	)))
+	assert.Equal(t, uint32(0), bc.Height())
+	assert.Equal(t, uint64(100), bc.BalanceOf(aliceAddr))
+	assert.Equal(t, uint64(0), bc.BalanceOf(bob))
+	assert.Equal(t, uint64(0), bc.NonceOf(aliceAddr))
+}
+
+func TestBlockchain_AddBlock_Replay(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+
+	tx := signedTransfer(t, alice, bc.NonceOf(aliceAddr), bob, 10)
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, tx)))
+	assert.Equal(t, uint64(1), bc.NonceOf(aliceAddr))
+
+	// 在后续区块中重放同一笔交易
+	assert.NotNil(t, bc.AddBlock(nextBlock(t, bc, tx)))
+	// 同一区块中的 nonce 必须连续
+	assert.NotNil(t, bc.AddBlock(nextBlock(t, bc,
+		signedTransfer(t, alice, 1, bob, 10),
+		signedTransfer(t, alice, 3, bob, 10),
+	)))
+	assert.Equal(t, uint64(90), bc.BalanceOf(aliceAddr))
+	assert.Equal(t, uint32(1), bc.Height())
+}
+
+func TestBlockchain_AddBlock_Fee(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	validator := crypto.GeneratePrivateKey()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100000})
+
+	tx := NewTransferTransaction(bob, 30)
+	tx.GasPrice = 2
+	assert.Nil(t, tx.Sign(alice))
+	assert.Nil(t, bc.AddBlock(nextBlockWithValidator(t, bc, validator, tx)))
+	assert.Equal(t, 100000-30-2*TxGas, bc.BalanceOf(aliceAddr))
+	assert.Equal(t, uint64(30), bc.BalanceOf(bob))
+	assert.Equal(t, 2*TxGas, bc.BalanceOf(validator.PublicKey().Address()))
+}
+
+func TestBlockchain_AddBlock_Reward(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	validator := crypto.GeneratePrivateKey()
+	validatorAddr := validator.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100000})
+	schedule := IssuanceSchedule{InitialReward: 8, HalvingInterval: 2}
+	bc.SetIssuanceSchedule(schedule)
+	assert.Equal(t, uint64(100000), bc.TotalSupply())
+
+	tx := NewTransferTransaction(randomAddress(), 10)
+	tx.GasPrice = 1
+	assert.Nil(t, tx.Sign(alice))
+	assert.Nil(t, bc.AddBlock(nextBlockWithValidator(t, bc, validator, tx)))
+	// 基础费用为 0 时手续费只是转移，不改变总供应量
+	assert.Equal(t, 8+TxGas, bc.BalanceOf(validatorAddr))
+	assert.Equal(t, uint64(100008), bc.TotalSupply())
+
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, bc.AddBlock(nextBlockWithValidator(t, bc, validator)))
+	}
+	// 奖励依次为 8 8 4 4 2
+	assert.Equal(t, 8+8+4+4+2+TxGas, bc.BalanceOf(validatorAddr))
+	for height, expected := range []uint64{100000, 100008, 100016, 100020, 100024, 100026} {
+		supply, err := bc.TotalSupplyAt(uint32(height))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, supply)
+	}
+	issued, _ := schedule.IssuedAt(bc.Height())
+	assert.Equal(t, 100000+issued, bc.TotalSupply())
+
+	_, err := bc.TotalSupplyAt(bc.Height() + 1)
+	assert.NotNil(t, err)
+}
+
+func TestBlockchain_AddBlock_BaseFee(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	validator := crypto.GeneratePrivateKey()
+	genesis := randomBlock(0, types.Hash{})
+	genesis.BaseFee = 8
+	state := NewState()
+	assert.Nil(t, state.AddBalance(aliceAddr, 1000000))
+	bc, err := NewBlockChainWithState(genesis, state)
+	assert.Nil(t, err)
+	bc.SetFeeMarket(FeeMarket{TargetUsage: TxGas, ChangeDenominator: 8})
+
+	// 创世区块为空，基础费用下降
+	assert.Equal(t, uint64(7), bc.NextBaseFee())
+	txs := make([]*Transaction, 3)
+	for i := range txs {
+		txs[i] = NewTransferTransaction(randomAddress(), 1)
+		txs[i].Nonce = uint64(i)
+		txs[i].GasPrice = 10
+		assert.Nil(t, txs[i].Sign(alice))
+	}
+
+	// 基础费用与根据父区块计算的结果不符
+	b := randomBlock(1, getPrevBlockHash(t, 1, bc))
+	b.BaseFee = 8
+	assert.Nil(t, b.Sign(validator))
+	assert.NotNil(t, bc.AddBlock(b))
+
+	assert.Nil(t, bc.AddBlock(nextBlockWithValidator(t, bc, validator, txs...)))
+	assert.Equal(t, 1000000-3*(1+10*TxGas), bc.BalanceOf(aliceAddr))
+	assert.Equal(t, 3*3*TxGas, bc.BalanceOf(validator.PublicKey().Address()))
+	assert.Equal(t, 1000000-3*7*TxGas, bc.TotalSupply())
+	// 区块用量为目标的三倍，基础费用上涨
+	assert.Equal(t, uint64(7+1), bc.NextBaseFee())
+}
+
+func TestBlockchain_AddBlock_StateRoot(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+
+	b := nextBlock(t, bc, signedTransfer(t, alice, 0, bob, 30))
+	assert.Nil(t, bc.AddBlock(b))
+	header, err := bc.GetHeader(1)
+	assert.Nil(t, err)
+	assert.Equal(t, bc.State().Root(), header.StateRoot)
+
+	proof := bc.ProveAccount(bob)
+	assert.Equal(t, Account{Balance: 30}, proof.Account)
+	assert.Nil(t, proof.Verify(header.StateRoot, bob))
+
+	// 状态根与应用交易后的状态不符
+	validator := crypto.GeneratePrivateKey()
+	b = nextBlockWithValidator(t, bc, validator, signedTransfer(t, alice, 1, bob, 10))
+	b.StateRoot = header.StateRoot
+	assert.Nil(t, b.Sign(validator))
+	assert.NotNil(t, bc.AddBlock(b))
+	assert.Equal(t, uint32(1), bc.Height())
+	assert.Equal(t, uint64(30), bc.BalanceOf(bob))
+}
+
+func TestBlockchain_AddBlock_GasLimit(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+	bc.SetBlockGasLimit(2 * TxGas)
+
+	txs := make([]*Transaction, 3)
+	for i := range txs {
+		txs[i] = signedTransfer(t, alice, uint64(i), randomAddress(), 1)
+	}
+	// 交易的 GasLimit 之和超过区块 gas 上限
+	assert.NotNil(t, bc.AddBlock(nextBlock(t, bc, txs...)))
+
+	// 区块头中消耗的 gas 与实际不符
+	validator := crypto.GeneratePrivateKey()
+	b := nextBlockWithValidator(t, bc, validator, txs[:2]...)
+	assert.Equal(t, 2*TxGas, b.GasUsed)
+	b.GasUsed = TxGas
+	assert.Nil(t, b.Sign(validator))
+	assert.NotNil(t, bc.AddBlock(b))
+
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, txs[:2]...)))
+	header, err := bc.GetHeader(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2*TxGas, header.GasUsed)
+}
+
+func TestBlockchain_AddBlock_Receipts(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 1000000})
+
+	// 合约以调用者地址为主题、调用金额为数据发出事件：
+	// CALLVALUE PUSH1 0 MSTORE CALLER PUSH1 32 PUSH1 0 LOG1
+	code := []byte{byte(vm.CALLVALUE), byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.CALLER),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG0 + 1)}
+	deploy := NewDeployTransaction(code)
+	assert.Nil(t, deploy.Sign(alice))
+	contract := ContractAddress(aliceAddr, 0)
+	call := NewCallTransaction(contract, nil, 100000)
+	call.Nonce = 1
+	call.Value = 5
+	assert.Nil(t, call.Sign(alice))
+	// gas 不足以发出事件
+	failing := NewCallTransaction(contract, nil, TxGas+100)
+	failing.Nonce = 2
+	assert.Nil(t, failing.Sign(alice))
+	b := nextBlock(t, bc, deploy, call, failing)
+	assert.False(t, b.ReceiptsRoot.IsZero())
+	assert.Nil(t, bc.AddBlock(b))
+
+	receipt, err := bc.GetReceipt(deploy.Hash(TxHasher{}))
+	assert.Nil(t, err)
+	assert.Equal(t, ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, contract, receipt.ContractAddress)
+	assert.Equal(t, uint32(1), receipt.BlockHeight)
+	assert.Equal(t, uint32(0), receipt.TxIndex)
+
+	receipt, err = bc.GetReceipt(call.Hash(TxHasher{}))
+	assert.Nil(t, err)
+	assert.Equal(t, ReceiptStatusSuccessful, receipt.Status)
+	// 转账事件在合约代码发出的事件之前
+	assert.Equal(t, []*vm.Log{{
+		Topics: []types.Hash{TransferTopic, AddressTopic(aliceAddr), AddressTopic(contract)},
+		Data:   []byte{0, 0, 0, 0, 0, 0, 0, 5},
+	}, {
+		Address: contract,
+		Topics:  []types.Hash{AddressTopic(aliceAddr)},
+		Data:    types.MustHashFromBytes(append(make([]byte, 31), 5)).ToSlice(),
+	}}, receipt.Logs)
+
+	receipt, err = bc.GetReceipt(failing.Hash(TxHasher{}))
+	assert.Nil(t, err)
+	assert.Equal(t, ReceiptStatusFailed, receipt.Status)
+	assert.Equal(t, failing.GasLimit, receipt.GasUsed)
+	assert.Empty(t, receipt.Logs)
+
+	receipts, err := bc.GetReceipts(1)
+	assert.Nil(t, err)
+	assert.Len(t, receipts, 3)
+	header, err := bc.GetHeader(1)
+	assert.Nil(t, err)
+	assert.Equal(t, ReceiptsRoot(receipts), header.ReceiptsRoot)
+	_, err = bc.GetReceipt(types.RandomHash())
+	assert.NotNil(t, err)
+
+	// 收据根与应用交易后的收据不符
+	validator := crypto.GeneratePrivateKey()
+	b = nextBlockWithValidator(t, bc, validator, signedTransfer(t, alice, 3, randomAddress(), 1))
+	b.ReceiptsRoot = header.ReceiptsRoot
+	assert.Nil(t, b.Sign(validator))
+	assert.NotNil(t, bc.AddBlock(b))
+}
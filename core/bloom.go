@@ -0,0 +1,69 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+const (
+	// BloomLength 是布隆过滤器的字节数
+	BloomLength = 256
+	// bloomHashes 是每个元素在布隆过滤器中设置的位数
+	bloomHashes = 3
+)
+
+// Bloom 是区块中所有事件的合约地址和主题组成的 2048 位布隆过滤器。
+// 每个元素取其 SHA256 摘要的前 3 个 16 位大端整数，对 2048 取模后设置对应的位。
+// Test 返回 false 时元素一定不在集合中，返回 true 时元素可能在集合中。
+type Bloom [BloomLength]byte
+
+// CreateBloom 返回收据中所有事件的合约地址和主题组成的布隆过滤器。
+func CreateBloom(receipts []*Receipt) Bloom {
+	var b Bloom
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			b.Add(log.Address.ToSlice())
+			for _, topic := range log.Topics {
+				b.Add(topic.ToSlice())
+			}
+		}
+	}
+	return b
+}
+
+// Add 将元素加入布隆过滤器。
+func (b *Bloom) Add(data []byte) {
+	for _, bit := range bloomBits(data) {
+		b[BloomLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// Test 判断元素是否可能在布隆过滤器中。
+func (b Bloom) Test(data []byte) bool {
+	for _, bit := range bloomBits(data) {
+		if b[BloomLength-1-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsZero 判断布隆过滤器是否为空。
+func (b Bloom) IsZero() bool {
+	return b == Bloom{}
+}
+
+func (b Bloom) String() string {
+	return hex.EncodeToString(b[:])
+}
+
+// bloomBits 返回元素在布隆过滤器中对应的位。
+func bloomBits(data []byte) [bloomHashes]uint {
+	sum := sha256.Sum256(data)
+	var bits [bloomHashes]uint
+	for i := range bits {
+		bits[i] = uint(binary.BigEndian.Uint16(sum[2*i:])) % (BloomLength * 8)
+	}
+	return bits
+}
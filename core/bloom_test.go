@@ -0,0 +1,30 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBloom(t *testing.T) {
+	var b Bloom
+	assert.True(t, b.IsZero())
+	data := types.RandomBytes(32)
+	assert.False(t, b.Test(data))
+	b.Add(data)
+	assert.False(t, b.IsZero())
+	assert.True(t, b.Test(data))
+}
+
+func TestCreateBloom(t *testing.T) {
+	addr, topic := randomAddress(), types.RandomHash()
+	receipts := []*Receipt{
+		{},
+		{Logs: []*vm.Log{{Address: addr, Topics: []types.Hash{topic}, Data: []byte{1}}}},
+	}
+	b := CreateBloom(receipts)
+	assert.True(t, b.Test(addr.ToSlice()))
+	assert.True(t, b.Test(topic.ToSlice()))
+	assert.True(t, CreateBloom(receipts[:1]).IsZero())
+}
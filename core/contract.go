@@ -0,0 +1,47 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// ContractAddress 返回 sender 以 nonce 发送的部署交易所创建的合约地址，
+// 即 sha256(sender || nonce) 的后 20 字节，其中 nonce 为 8 字节大端编码。
+func ContractAddress(sender types.Address, nonce uint64) types.Address {
+	buf := make([]byte, 0, len(sender)+8)
+	buf = append(buf, sender.ToSlice()...)
+	buf = binary.BigEndian.AppendUint64(buf, nonce)
+	hash := sha256.Sum256(buf)
+	return types.MustAddressFromBytes(hash[len(hash)-20:])
+}
+
+// deploy 将 code 部署到 addr，每个代码字节消耗 CodeDepositGas，返回剩余的 gas。
+// 地址已有代码或已发送过交易时部署失败，失败时消耗所有 gas。
+func (s *State) deploy(addr types.Address, code []byte, gas uint64) (uint64, error) {
+	if acc := s.GetAccount(addr); acc.IsContract() || acc.Nonce > 0 {
+		return 0, fmt.Errorf("contract address %s is already in use", addr)
+	}
+	depositGas, ok := mulUint64(uint64(len(code)), CodeDepositGas)
+	if !ok || depositGas > gas {
+		return 0, fmt.Errorf("code deposit of %d bytes: %w", len(code), vm.ErrOutOfGas)
+	}
+	s.SetCode(addr, code)
+	return gas - depositGas, nil
+}
+
+// call 以交易的 Data 为输入，在合约账户 tx.To 下使用给定的 gas 执行合约代码，返回执行结果、发出的事件和剩余的 gas。
+func (s *State) call(ctx BlockContext, tx *Transaction, from types.Address, code []byte, gas uint64) ([]byte, []*vm.Log, uint64, error) {
+	machine := vm.New(vm.Context{
+		Address:   tx.To,
+		Caller:    from,
+		Value:     tx.Value,
+		Input:     tx.Data,
+		Height:    ctx.Height,
+		Timestamp: ctx.Timestamp,
+	}, s)
+	ret, gasLeft, err := machine.Run(code, gas)
+	return ret, machine.Logs(), gasLeft, err
+}
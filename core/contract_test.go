@@ -0,0 +1,92 @@
+package core
+
+import (
+	"MyChain/crypto"
+	"MyChain/types"
+	"MyChain/vm"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestContractAddress(t *testing.T) {
+	sender := randomAddress()
+	assert.Equal(t, ContractAddress(sender, 0), ContractAddress(sender, 0))
+	assert.NotEqual(t, ContractAddress(sender, 0), ContractAddress(sender, 1))
+	assert.NotEqual(t, ContractAddress(sender, 0), ContractAddress(randomAddress(), 0))
+}
+
+func TestState_ApplyTransaction_Deploy(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 1000000))
+	code := []byte{byte(vm.PUSH1), 0x2a, byte(vm.POP)}
+
+	tx := NewDeployTransaction(code)
+	tx.Value = 100
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Equal(t, ContractAddress(from, 0), result.ContractAddress)
+	assert.Equal(t, TxGas+TxCreateGas+uint64(len(code))*(TxDataGas+CodeDepositGas), result.GasUsed)
+	assert.Equal(t, code, s.GetCode(result.ContractAddress))
+	assert.True(t, s.GetAccount(result.ContractAddress).IsContract())
+	assert.Equal(t, uint64(100), s.Balance(result.ContractAddress))
+	assert.Nil(t, s.GetCode(from))
+
+	// 保存代码的 gas 不足时部署失败，金额不转入合约
+	tx = NewDeployTransaction(code)
+	tx.Nonce = 1
+	tx.Value = 100
+	tx.GasLimit = IntrinsicGas(tx)
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err = s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(result.Err, vm.ErrOutOfGas))
+	assert.Nil(t, s.GetCode(result.ContractAddress))
+	assert.Equal(t, uint64(0), s.Balance(result.ContractAddress))
+	assert.Equal(t, uint64(2), s.Nonce(from))
+
+	// 部署交易不能指定接收者，也必须携带代码
+	tx = NewDeployTransaction(code)
+	tx.Nonce = 2
+	tx.To = randomAddress()
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, BlockContext{}, tx))
+	tx = NewDeployTransaction(nil)
+	tx.Nonce = 2
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, BlockContext{}, tx))
+}
+
+func TestState_ApplyTransaction_DeployCollision(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 1000000))
+
+	// 合约地址上已经有代码
+	s.SetCode(ContractAddress(from, 0), []byte{byte(vm.STOP)})
+	tx := NewDeployTransaction([]byte{byte(vm.STOP), byte(vm.STOP)})
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.True(t, result.Failed())
+	assert.Equal(t, tx.GasLimit, result.GasUsed)
+	assert.Equal(t, []byte{byte(vm.STOP)}, s.GetCode(result.ContractAddress))
+}
+
+func TestState_Root_Code(t *testing.T) {
+	s := NewState()
+	addr := randomAddress()
+	root := s.Root()
+	s.SetCode(addr, []byte{byte(vm.STOP)})
+	assert.NotEqual(t, root, s.Root())
+
+	c := s.Copy()
+	assert.Equal(t, s.Root(), c.Root())
+	assert.Equal(t, []byte{byte(vm.STOP)}, c.GetCode(addr))
+	assert.Equal(t, types.Hash{}, c.StorageRoot(addr))
+}
@@ -1,7 +1,6 @@
 package core
 
 import (
-	"crypto/elliptic"
 	"encoding/gob"
 	"io"
 )
@@ -29,7 +28,6 @@ type GobTxEncoder struct {
 }
 
 func NewGobTxEncoder(w io.Writer) *GobTxEncoder {
-	gob.Register(elliptic.P256())
 	return &GobTxEncoder{w: w}
 }
 func (e *GobTxEncoder) Encode(tx *Transaction) error {
@@ -41,9 +39,14 @@ type GobTxDecoder struct {
 }
 
 func NewGobTxDecoder(r io.Reader) *GobTxDecoder {
-	gob.Register(elliptic.P256())
 	return &GobTxDecoder{r: r}
 }
+
+// Decode 解码交易，并按交易种类的标签分派给对应的处理器检查交易的格式，
+// 种类未注册或不符合该种类要求的交易返回错误。
 func (d *GobTxDecoder) Decode(tx *Transaction) error {
-	return gob.NewDecoder(d.r).Decode(tx)
+	if err := gob.NewDecoder(d.r).Decode(tx); err != nil {
+		return err
+	}
+	return tx.Validate()
 }
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"math"
+	"math/bits"
+)
+
+// DefaultFeeMarket 是节点默认使用的基础费用调整规则：每个区块的目标用量为默认区块 gas 上限的一半，
+// 每个区块基础费用最多变化 1/8，且不低于 1。
+var DefaultFeeMarket = FeeMarket{
+	TargetUsage:       DefaultBlockGasLimit / 2,
+	ChangeDenominator: 8,
+	MinBaseFee:        1,
+}
+
+// FeeMarket 描述区块基础费用随拥堵程度调整的规则。父区块的用量高于目标时基础费用上升，
+// 低于目标时下降，每个区块最多变化父区块基础费用的 1/ChangeDenominator。
+// 区块用量为区块消耗的 gas。零值表示基础费用固定不变。
+type FeeMarket struct {
+	// TargetUsage 是每个区块的目标用量，为 0 时基础费用固定不变
+	TargetUsage uint64
+	// ChangeDenominator 限制每个区块基础费用的最大变化比例，为 0 时按 1 处理
+	ChangeDenominator uint64
+	// MinBaseFee 是基础费用的下限
+	MinBaseFee uint64
+}
+
+// NextBaseFee 根据父区块的基础费用和用量计算下一个区块的基础费用。
+//
+// 参数:
+//
+//	parentBaseFee - 父区块的基础费用。
+//	parentUsage - 父区块的用量。
+//
+// 返回值:
+//
+//	uint64 - 下一个区块的基础费用。
+func (m FeeMarket) NextBaseFee(parentBaseFee, parentUsage uint64) uint64 {
+	if m.TargetUsage == 0 {
+		return parentBaseFee
+	}
+	denominator := m.ChangeDenominator
+	if denominator == 0 {
+		denominator = 1
+	}
+	next := parentBaseFee
+	switch {
+	case parentUsage > m.TargetUsage:
+		delta := mulDiv(parentBaseFee, parentUsage-m.TargetUsage, m.TargetUsage) / denominator
+		// 超过目标时至少上涨 1，避免基础费用为 0 或很小时无法上涨
+		if delta == 0 {
+			delta = 1
+		}
+		if next > math.MaxUint64-delta {
+			next = math.MaxUint64
+		} else {
+			next += delta
+		}
+	case parentUsage < m.TargetUsage:
+		delta := mulDiv(parentBaseFee, m.TargetUsage-parentUsage, m.TargetUsage) / denominator
+		next -= delta
+	}
+	if next < m.MinBaseFee {
+		next = m.MinBaseFee
+	}
+	return next
+}
+
+// mulDiv 计算 a*b/c，结果超出 uint64 范围时返回 uint64 的最大值。
+func mulDiv(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		return math.MaxUint64
+	}
+	q, _ := bits.Div64(hi, lo, c)
+	return q
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestFeeMarket_NextBaseFee(t *testing.T) {
+	m := FeeMarket{TargetUsage: 10, ChangeDenominator: 8, MinBaseFee: 1}
+	assert.Equal(t, uint64(800), m.NextBaseFee(800, 10))
+	// 满载（两倍目标）时上涨 1/8，空块时下降 1/8
+	assert.Equal(t, uint64(900), m.NextBaseFee(800, 20))
+	assert.Equal(t, uint64(700), m.NextBaseFee(800, 0))
+	assert.Equal(t, uint64(850), m.NextBaseFee(800, 15))
+	// 超过目标时至少上涨 1，且不低于下限
+	assert.Equal(t, uint64(2), m.NextBaseFee(1, 11))
+	assert.Equal(t, uint64(1), m.NextBaseFee(1, 0))
+	assert.Equal(t, uint64(1), m.NextBaseFee(0, 10))
+	assert.Equal(t, uint64(math.MaxUint64), m.NextBaseFee(math.MaxUint64, 1000))
+
+	// 零值不调整基础费用
+	assert.Equal(t, uint64(800), FeeMarket{}.NextBaseFee(800, 1000))
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"fmt"
+	"slices"
+)
+
+// FilterQuery 是事件查询的条件。
+type FilterQuery struct {
+	// FromHeight 和 ToHeight 是查询的区块高度范围，两端都包含在内
+	FromHeight uint32
+	ToHeight   uint32
+	// Addresses 不为空时只匹配其中的合约发出的事件，原生货币转账事件的地址为零地址
+	Addresses []types.Address
+	// Topics 的第 i 个元素不为空时，要求事件的第 i 个主题是其中之一，为空时第 i 个主题可以是任意值
+	Topics [][]types.Hash
+}
+
+// FilteredLog 是查询到的事件及其在区块链中的位置。
+type FilteredLog struct {
+	*vm.Log
+	BlockHeight uint32
+	TxHash      types.Hash
+	TxIndex     uint32
+	// LogIndex 是事件在交易所有事件中的下标
+	LogIndex uint32
+}
+
+// FilterLogs 返回高度范围内所有满足查询条件的事件，按区块、交易和事件的顺序排列。
+// 区块头的布隆过滤器表明区块中不可能含有匹配的事件时，不读取该区块的收据。
+//
+// 参数:
+//
+//	q - 查询条件。
+//
+// 返回值:
+//
+//	[]*FilteredLog - 匹配的事件。
+//	error - 高度范围无效或超过当前区块链高度时返回错误。
+func (bc *Blockchain) FilterLogs(q FilterQuery) ([]*FilteredLog, error) {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	if q.FromHeight > q.ToHeight {
+		return nil, fmt.Errorf("invalid height range [%d, %d]", q.FromHeight, q.ToHeight)
+	}
+	if int(q.ToHeight) >= len(bc.headers) {
+		return nil, fmt.Errorf("blockchain height is %d, but get %d", len(bc.headers)-1, q.ToHeight)
+	}
+	var logs []*FilteredLog
+	for height := q.FromHeight; ; height++ {
+		if q.mayMatch(bc.headers[height].LogsBloom) {
+			for _, receipt := range bc.receipts[height] {
+				for i, log := range receipt.Logs {
+					if !q.matches(log) {
+						continue
+					}
+					logs = append(logs, &FilteredLog{
+						Log:         log,
+						BlockHeight: receipt.BlockHeight,
+						TxHash:      receipt.TxHash,
+						TxIndex:     receipt.TxIndex,
+						LogIndex:    uint32(i),
+					})
+				}
+			}
+		}
+		// 避免 ToHeight 为最大值时溢出
+		if height == q.ToHeight {
+			break
+		}
+	}
+	return logs, nil
+}
+
+// mayMatch 判断布隆过滤器对应的区块中是否可能含有满足查询条件的事件。
+func (q FilterQuery) mayMatch(bloom Bloom) bool {
+	if len(q.Addresses) > 0 && !slices.ContainsFunc(q.Addresses, func(addr types.Address) bool {
+		return bloom.Test(addr.ToSlice())
+	}) {
+		return false
+	}
+	for _, topics := range q.Topics {
+		if len(topics) > 0 && !slices.ContainsFunc(topics, func(topic types.Hash) bool {
+			return bloom.Test(topic.ToSlice())
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches 判断事件是否满足查询条件。
+func (q FilterQuery) matches(log *vm.Log) bool {
+	if len(q.Addresses) > 0 && !slices.Contains(q.Addresses, log.Address) {
+		return false
+	}
+	for i, topics := range q.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		// 事件的主题少于查询条件要求的位置时不匹配
+		if i >= len(log.Topics) || !slices.Contains(topics, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
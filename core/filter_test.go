@@ -0,0 +1,131 @@
+package core
+
+import (
+	"MyChain/crypto"
+	"MyChain/types"
+	"MyChain/vm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// callerLogCode 是以调用者地址为唯一主题、不带数据发出事件的合约代码：CALLER PUSH1 0 PUSH1 0 LOG1
+var callerLogCode = []byte{byte(vm.CALLER), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG0 + 1)}
+
+func TestBlockchain_FilterLogs(t *testing.T) {
+	alice, bob := crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey()
+	aliceAddr, bobAddr := alice.PublicKey().Address(), bob.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 1000000, bobAddr: 1000000})
+	contract := ContractAddress(aliceAddr, 0)
+
+	deploy := NewDeployTransaction(callerLogCode)
+	assert.Nil(t, deploy.Sign(alice))
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, deploy)))
+	call := func(key crypto.PrivateKey, nonce uint64) *Transaction {
+		tx := NewCallTransaction(contract, nil, 100000)
+		tx.Nonce = nonce
+		assert.Nil(t, tx.Sign(key))
+		return tx
+	}
+	aliceCall := call(alice, 1)
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, aliceCall)))
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, call(bob, 0), call(bob, 1))))
+	transfer := signedTransfer(t, alice, 2, bobAddr, 1)
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, transfer)))
+	header, err := bc.GetHeader(4)
+	assert.Nil(t, err)
+	assert.True(t, header.LogsBloom.Test(TransferTopic.ToSlice()))
+	assert.False(t, header.LogsBloom.Test(contract.ToSlice()))
+
+	logs, err := bc.FilterLogs(FilterQuery{ToHeight: 4, Addresses: []types.Address{contract}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 3)
+
+	logs, err = bc.FilterLogs(FilterQuery{ToHeight: 4, Topics: [][]types.Hash{{AddressTopic(aliceAddr)}}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, &FilteredLog{
+		Log:         &vm.Log{Address: contract, Topics: []types.Hash{AddressTopic(aliceAddr)}, Data: []byte{}},
+		BlockHeight: 2,
+		TxHash:      aliceCall.Hash(TxHasher{}),
+	}, logs[0])
+
+	// 主题位置为空时匹配任意值，事件的主题少于查询条件要求的位置时不匹配
+	logs, err = bc.FilterLogs(FilterQuery{FromHeight: 3, ToHeight: 3, Topics: [][]types.Hash{nil}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, uint32(1), logs[1].TxIndex)
+	// 只有 alice 的转账事件以 alice 为第二个主题
+	logs, err = bc.FilterLogs(FilterQuery{ToHeight: 4, Topics: [][]types.Hash{nil, {AddressTopic(aliceAddr)}}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, transfer.Hash(TxHasher{}), logs[0].TxHash)
+
+	logs, err = bc.FilterLogs(FilterQuery{ToHeight: 4, Addresses: []types.Address{randomAddress()}})
+	assert.Nil(t, err)
+	assert.Empty(t, logs)
+
+	_, err = bc.FilterLogs(FilterQuery{FromHeight: 2, ToHeight: 1})
+	assert.NotNil(t, err)
+	_, err = bc.FilterLogs(FilterQuery{ToHeight: 5})
+	assert.NotNil(t, err)
+}
+
+func TestBlockchain_FilterLogs_Transfers(t *testing.T) {
+	alice, bob := crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey()
+	aliceAddr, bobAddr := alice.PublicKey().Address(), bob.PublicKey().Address()
+	carol := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 1000000, bobAddr: 1000000})
+
+	deploy := NewDeployTransaction(callerLogCode)
+	deploy.Value = 7
+	assert.Nil(t, deploy.Sign(alice))
+	toCarol := signedTransfer(t, alice, 1, carol, 10)
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, deploy, toCarol, signedTransfer(t, bob, 0, aliceAddr, 20))))
+	// 不转账的调用和失败的转账都不发出转账事件
+	call := NewCallTransaction(ContractAddress(aliceAddr, 0), nil, 100000)
+	call.Nonce = 1
+	assert.Nil(t, call.Sign(bob))
+	failing := NewCallTransaction(ContractAddress(aliceAddr, 0), nil, TxGas+100)
+	failing.Nonce = 2
+	failing.Value = 5
+	assert.Nil(t, failing.Sign(alice))
+	fromBob := signedTransfer(t, bob, 2, carol, 30)
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, call, failing, fromBob)))
+
+	// 所有转给 carol 的原生货币
+	logs, err := bc.FilterLogs(FilterQuery{ToHeight: 2, Topics: [][]types.Hash{{TransferTopic}, nil, {AddressTopic(carol)}}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, &FilteredLog{
+		Log: &vm.Log{
+			Topics: []types.Hash{TransferTopic, AddressTopic(aliceAddr), AddressTopic(carol)},
+			Data:   []byte{0, 0, 0, 0, 0, 0, 0, 10},
+		},
+		BlockHeight: 1,
+		TxHash:      toCarol.Hash(TxHasher{}),
+		TxIndex:     1,
+	}, logs[0])
+	assert.Equal(t, fromBob.Hash(TxHasher{}), logs[1].TxHash)
+	assert.Equal(t, uint32(2), logs[1].TxIndex)
+
+	// 原生货币转账事件的地址为零地址
+	logs, err = bc.FilterLogs(FilterQuery{ToHeight: 2, Addresses: []types.Address{{}}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 4)
+	logs, err = bc.FilterLogs(FilterQuery{ToHeight: 2, Topics: [][]types.Hash{{TransferTopic}, {AddressTopic(aliceAddr)}}})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 2)
+}
+
+func TestFilterQuery_MayMatch(t *testing.T) {
+	addr, topic := randomAddress(), types.RandomHash()
+	var bloom Bloom
+	bloom.Add(addr.ToSlice())
+	bloom.Add(topic.ToSlice())
+
+	assert.True(t, FilterQuery{}.mayMatch(Bloom{}))
+	assert.True(t, FilterQuery{Addresses: []types.Address{randomAddress(), addr}}.mayMatch(bloom))
+	assert.True(t, FilterQuery{Topics: [][]types.Hash{nil, {topic}}}.mayMatch(bloom))
+	assert.False(t, FilterQuery{Addresses: []types.Address{addr}}.mayMatch(Bloom{}))
+	assert.False(t, FilterQuery{Addresses: []types.Address{addr}, Topics: [][]types.Hash{{types.RandomHash()}}}.mayMatch(bloom))
+}
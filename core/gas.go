@@ -0,0 +1,25 @@
+package core
+
+const (
+	// TxGas 是每笔交易固定消耗的 gas
+	TxGas uint64 = 21000
+	// TxDataGas 是交易 Data 中每个字节消耗的 gas
+	TxDataGas uint64 = 16
+	// TxSignatureGas 是多签交易中多签账户的每个成员额外消耗的 gas，用于抵偿验证签名的开销
+	TxSignatureGas uint64 = 3000
+	// TxCreateGas 是部署合约的交易额外固定消耗的 gas
+	TxCreateGas uint64 = 32000
+	// CodeDepositGas 是部署合约时保存代码的每个字节消耗的 gas，在执行阶段扣除
+	CodeDepositGas uint64 = 200
+	// DefaultBlockGasLimit 是新区块链默认的区块 gas 上限
+	DefaultBlockGasLimit uint64 = 10000000
+)
+
+// IntrinsicGas 返回交易在执行之前固定消耗的 gas，由交易种类的处理器决定，
+// 例如部署合约的交易在 BaseIntrinsicGas 之外还需要 TxCreateGas。未注册的种类按 BaseIntrinsicGas 计算。
+func IntrinsicGas(tx *Transaction) uint64 {
+	if h, ok := LookupTxHandler(tx.Kind); ok {
+		return h.IntrinsicGas(tx)
+	}
+	return BaseIntrinsicGas(tx)
+}
@@ -32,6 +32,15 @@ func (BlockHasher) Hash(h *Header) types.Hash {
 type TxHasher struct {
 }
 
+// Hash 计算交易的哈希值，即签名内容与签名的 SHA256 摘要，作为交易的唯一标识。
+// 签名按 RecoverableBytes 序列化，未经校验的交易（包括空的或格式错误的签名）也可以计算哈希。
 func (TxHasher) Hash(tx *Transaction) types.Hash {
-	return types.Hash(sha256.Sum256(tx.Data))
+	b := tx.SigningBytes()
+	if tx.Signature != nil {
+		b = append(b, tx.Signature.RecoverableBytes()...)
+	}
+	for _, sig := range tx.Signatures {
+		b = append(b, sig.RecoverableBytes()...)
+	}
+	return types.Hash(sha256.Sum256(b))
 }
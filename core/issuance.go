@@ -0,0 +1,80 @@
+package core
+
+import "math"
+
+// DefaultIssuanceSchedule 是节点默认使用的发行计划：每个区块奖励 50，每 210000 个区块减半。
+var DefaultIssuanceSchedule = IssuanceSchedule{
+	InitialReward:   50,
+	HalvingInterval: 210000,
+}
+
+// IssuanceSchedule 描述原生货币的发行计划。签名区块的验证者获得该区块高度对应的奖励，
+// 奖励从 InitialReward 开始，每经过 HalvingInterval 个区块减半，直到为 0。
+// 零值表示不发行任何货币。
+type IssuanceSchedule struct {
+	// InitialReward 是高度 1 的区块奖励
+	InitialReward uint64
+	// HalvingInterval 是奖励减半的区块间隔，为 0 时奖励永不减半
+	HalvingInterval uint32
+}
+
+// RewardAt 返回指定高度区块的奖励，创世区块没有奖励。
+//
+// 参数:
+//
+//	height - 区块高度。
+//
+// 返回值:
+//
+//	uint64 - 该高度区块的奖励。
+func (s IssuanceSchedule) RewardAt(height uint32) uint64 {
+	if height == 0 {
+		return 0
+	}
+	if s.HalvingInterval == 0 {
+		return s.InitialReward
+	}
+	halvings := (height - 1) / s.HalvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+	return s.InitialReward >> halvings
+}
+
+// IssuedAt 返回从高度 1 到 height（包含）所有区块奖励的总和，不包括创世状态中的余额。
+// 结果超出 uint64 范围时返回 false。
+//
+// 参数:
+//
+//	height - 区块高度。
+//
+// 返回值:
+//
+//	uint64 - 累计发行量。
+//	bool - 累计发行量是否没有溢出。
+func (s IssuanceSchedule) IssuedAt(height uint32) (uint64, bool) {
+	interval := s.HalvingInterval
+	if interval == 0 {
+		interval = height
+	}
+	var total uint64
+	// 按减半周期分段累加，每段内奖励相同
+	for start := uint32(1); start <= height; start += interval {
+		reward := s.RewardAt(start)
+		if reward == 0 {
+			break
+		}
+		blocks := uint64(interval)
+		if remaining := uint64(height-start) + 1; remaining < blocks {
+			blocks = remaining
+		}
+		if reward > (math.MaxUint64-total)/blocks {
+			return 0, false
+		}
+		total += reward * blocks
+		if uint64(start)+uint64(interval) > uint64(height) {
+			break
+		}
+	}
+	return total, true
+}
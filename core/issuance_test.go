@@ -0,0 +1,39 @@
+package core
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestIssuanceSchedule_RewardAt(t *testing.T) {
+	s := IssuanceSchedule{InitialReward: 100, HalvingInterval: 10}
+	assert.Equal(t, uint64(0), s.RewardAt(0))
+	assert.Equal(t, uint64(100), s.RewardAt(1))
+	assert.Equal(t, uint64(100), s.RewardAt(10))
+	assert.Equal(t, uint64(50), s.RewardAt(11))
+	assert.Equal(t, uint64(25), s.RewardAt(21))
+	assert.Equal(t, uint64(0), s.RewardAt(10*64+1))
+	assert.Equal(t, uint64(0), IssuanceSchedule{}.RewardAt(1))
+	assert.Equal(t, uint64(7), IssuanceSchedule{InitialReward: 7}.RewardAt(math.MaxUint32))
+}
+
+func TestIssuanceSchedule_IssuedAt(t *testing.T) {
+	s := IssuanceSchedule{InitialReward: 100, HalvingInterval: 10}
+	for _, height := range []uint32{0, 1, 5, 10, 11, 25, 100, 1000} {
+		var expected uint64
+		for h := uint32(1); h <= height; h++ {
+			expected += s.RewardAt(h)
+		}
+		issued, ok := s.IssuedAt(height)
+		assert.True(t, ok)
+		assert.Equal(t, expected, issued, "height %d", height)
+	}
+
+	issued, ok := IssuanceSchedule{InitialReward: 3}.IssuedAt(math.MaxUint32)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3)*math.MaxUint32, issued)
+
+	_, ok = IssuanceSchedule{InitialReward: math.MaxUint64}.IssuedAt(2)
+	assert.False(t, ok)
+}
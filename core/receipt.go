@@ -0,0 +1,108 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// ReceiptStatus 表示交易的部署或代码执行是否成功。
+type ReceiptStatus byte
+
+const (
+	ReceiptStatusFailed ReceiptStatus = iota
+	ReceiptStatusSuccessful
+)
+
+func (s ReceiptStatus) String() string {
+	if s == ReceiptStatusSuccessful {
+		return "successful"
+	}
+	return "failed"
+}
+
+// Receipt 是交易被打包进区块并应用后的记录。
+type Receipt struct {
+	// Status 表示交易的部署或代码执行是否成功，失败的交易同样被打包并消耗 gas
+	Status ReceiptStatus
+	// GasUsed 是交易实际消耗的 gas
+	GasUsed uint64
+	// ContractAddress 是部署合约的交易创建的合约地址，其他交易为零值
+	ContractAddress types.Address
+	// Logs 是交易发出的事件
+	Logs []*vm.Log
+
+	// 以下字段用于查询，可以由区块推导，不参与收据根的计算
+	TxHash      types.Hash
+	BlockHeight uint32
+	TxIndex     uint32
+}
+
+// NewReceipt 根据交易的执行结果创建收据。
+//
+// 参数:
+//
+//	tx - 已应用的交易。
+//	result - 交易的执行结果。
+//	height - 交易所在区块的高度。
+//	index - 交易在区块中的下标。
+//
+// 返回值:
+//
+//	*Receipt - 交易的收据。
+func NewReceipt(tx *Transaction, result *ExecutionResult, height uint32, index uint32) *Receipt {
+	status := ReceiptStatusSuccessful
+	if result.Failed() {
+		status = ReceiptStatusFailed
+	}
+	return &Receipt{
+		Status:          status,
+		GasUsed:         result.GasUsed,
+		ContractAddress: result.ContractAddress,
+		Logs:            result.Logs,
+		TxHash:          tx.Hash(TxHasher{}),
+		BlockHeight:     height,
+		TxIndex:         index,
+	}
+}
+
+// Bytes 返回收据的确定性编码，用于计算收据根。编码依次为 Status、GasUsed、ContractAddress 和事件个数，
+// 随后每个事件依次为地址、主题个数、主题和带长度前缀的数据，整数均为大端编码。
+func (r *Receipt) Bytes() []byte {
+	b := make([]byte, 0, 1+8+20+4)
+	b = append(b, byte(r.Status))
+	b = binary.BigEndian.AppendUint64(b, r.GasUsed)
+	b = append(b, r.ContractAddress.ToSlice()...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(r.Logs)))
+	for _, log := range r.Logs {
+		b = append(b, log.Address.ToSlice()...)
+		b = append(b, byte(len(log.Topics)))
+		for _, topic := range log.Topics {
+			b = append(b, topic[:]...)
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(len(log.Data)))
+		b = append(b, log.Data...)
+	}
+	return b
+}
+
+// Hash 返回收据编码的 SHA256 摘要。
+func (r *Receipt) Hash() types.Hash {
+	return sha256.Sum256(r.Bytes())
+}
+
+// ReceiptsRoot 返回区块收据的根哈希，即以交易下标的 SHA256 摘要为键、收据哈希为值的稀疏默克尔树的根哈希。
+// 没有收据时返回零哈希。
+func ReceiptsRoot(receipts []*Receipt) types.Hash {
+	t := NewSparseMerkleTree()
+	for i, receipt := range receipts {
+		t.Set(receiptKey(uint32(i)), receipt.Hash())
+	}
+	return t.Root()
+}
+
+// receiptKey 返回交易下标在收据树中的键。
+func receiptKey(index uint32) types.Hash {
+	return sha256.Sum256(binary.BigEndian.AppendUint32(nil, index))
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReceiptsRoot(t *testing.T) {
+	assert.Equal(t, types.Hash{}, ReceiptsRoot(nil))
+
+	a := &Receipt{Status: ReceiptStatusSuccessful, GasUsed: TxGas}
+	b := &Receipt{Status: ReceiptStatusFailed, GasUsed: 2 * TxGas}
+	root := ReceiptsRoot([]*Receipt{a, b})
+	assert.NotEqual(t, types.Hash{}, root)
+	// 收据的顺序参与计算
+	assert.NotEqual(t, root, ReceiptsRoot([]*Receipt{b, a}))
+
+	// 查询用的字段不参与计算
+	a.TxHash = types.RandomHash()
+	a.BlockHeight = 7
+	assert.Equal(t, root, ReceiptsRoot([]*Receipt{a, b}))
+
+	a.Logs = []*vm.Log{{Address: randomAddress(), Topics: []types.Hash{types.RandomHash()}}}
+	withLog := ReceiptsRoot([]*Receipt{a, b})
+	assert.NotEqual(t, root, withLog)
+	a.Logs[0].Data = []byte{1}
+	assert.NotEqual(t, withLog, ReceiptsRoot([]*Receipt{a, b}))
+}
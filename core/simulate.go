@@ -0,0 +1,49 @@
+package core
+
+import (
+	"MyChain/types"
+	"time"
+)
+
+// Simulate 在当前高度的世界状态上模拟执行交易，不修改区块链的状态。
+// 等同于以当前高度调用 SimulateAt。
+func (bc *Blockchain) Simulate(from types.Address, tx *Transaction) (*ExecutionResult, error) {
+	return bc.SimulateAt(from, tx, bc.Height())
+}
+
+// SimulateAt 在指定高度的世界状态上模拟执行交易，即假设交易被打包进接在该高度之后的区块，
+// 区块的基础费用根据该高度的区块头计算，时间戳为当前时间，没有验证者。
+// 交易在状态的拷贝上执行，不修改区块链的状态。交易不需要签名，发送者由 from 指定，
+// 因此钱包可以在签名之前得知交易是否会成功以及消耗的 gas。交易携带的签名不被验证，但必须是规范的形式。
+//
+// 参数:
+//
+//	from - 交易的发送者。
+//	tx - 需要模拟执行的交易。
+//	height - 执行交易所基于的区块高度。
+//
+// 返回值:
+//
+//	*ExecutionResult - 交易的执行结果，包括消耗的 gas、返回数据、事件和部署或代码执行失败的原因。
+//	error - 交易格式或签名格式错误、高度超过当前区块链高度、该高度的状态已被丢弃，
+//	或交易无法被打包（如 nonce 不匹配、余额不足）时返回错误。
+func (bc *Blockchain) SimulateAt(from types.Address, tx *Transaction, height uint32) (*ExecutionResult, error) {
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+	state, err := bc.StateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	bc.lock.RLock()
+	header := bc.headers[height]
+	baseFee := bc.feeMarket.NextBaseFee(header.BaseFee, header.GasUsed)
+	bc.lock.RUnlock()
+
+	ctx := BlockContext{
+		Height:    height + 1,
+		Timestamp: time.Now().UnixNano(),
+		BaseFee:   baseFee,
+	}
+	return state.applyTransaction(ctx, tx, from)
+}
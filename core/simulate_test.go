@@ -0,0 +1,153 @@
+package core
+
+import (
+	"MyChain/crypto"
+	"MyChain/types"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestBlockchain_Simulate(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 1000000})
+	contract := ContractAddress(aliceAddr, 0)
+	deploy := NewDeployTransaction(callerLogCode)
+	assert.Nil(t, deploy.Sign(alice))
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, deploy)))
+	balance := bc.BalanceOf(aliceAddr)
+	root := bc.State().Root()
+
+	// 交易不需要签名，模拟执行不修改区块链的状态
+	tx := NewCallTransaction(contract, nil, 100000)
+	tx.Nonce = 1
+	result, err := bc.Simulate(aliceAddr, tx)
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Len(t, result.Logs, 1)
+	assert.Equal(t, balance, bc.BalanceOf(aliceAddr))
+	assert.Equal(t, uint64(1), bc.NonceOf(aliceAddr))
+	assert.Equal(t, root, bc.State().Root())
+
+	// 模拟得到的 gas 与实际执行一致
+	assert.Nil(t, tx.Sign(alice))
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, tx)))
+	receipt, err := bc.GetReceipt(tx.Hash(TxHasher{}))
+	assert.Nil(t, err)
+	assert.Equal(t, result.GasUsed, receipt.GasUsed)
+
+	// 在合约部署之前的高度上，调用不执行任何代码
+	tx = NewCallTransaction(contract, nil, 100000)
+	result, err = bc.SimulateAt(aliceAddr, tx, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, TxGas, result.GasUsed)
+	assert.Empty(t, result.Logs)
+
+	// gas 不足时执行失败，但交易仍然可以被打包
+	tx = NewCallTransaction(contract, nil, TxGas+100)
+	tx.Nonce = 2
+	result, err = bc.Simulate(aliceAddr, tx)
+	assert.Nil(t, err)
+	assert.True(t, result.Failed())
+	assert.Equal(t, tx.GasLimit, result.GasUsed)
+
+	// 无法被打包的交易返回错误
+	tx.Nonce = 1
+	_, err = bc.Simulate(aliceAddr, tx)
+	assert.NotNil(t, err)
+	_, err = bc.SimulateAt(aliceAddr, tx, 3)
+	assert.NotNil(t, err)
+}
+
+func TestBlockchain_Simulate_MalformedSignature(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 1000000})
+
+	malformed := []*Transaction{
+		NewTransferTransaction(randomAddress(), 1),
+		NewTransferTransaction(randomAddress(), 1),
+	}
+	malformed[0].Signature = &crypto.Signature{S: big.NewInt(1)}
+	malformed[1].Signatures = []*crypto.Signature{nil}
+	for i, tx := range malformed {
+		assert.NotPanics(t, func() {
+			_, err := bc.Simulate(aliceAddr, tx)
+			assert.NotNil(t, err, "transaction %d", i)
+		})
+	}
+
+	// 未签名的交易可以模拟
+	_, err := bc.Simulate(aliceAddr, NewTransferTransaction(randomAddress(), 1))
+	assert.Nil(t, err)
+}
+
+func TestBlockchain_StateAt(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, signedTransfer(t, alice, 0, bob, 30))))
+
+	state, err := bc.StateAt(0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), state.Balance(bob))
+	state, err = bc.StateAt(1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(30), state.Balance(bob))
+	header, err := bc.GetHeader(1)
+	assert.Nil(t, err)
+	assert.Equal(t, header.StateRoot, state.Root())
+
+	// 修改返回的拷贝不影响区块链
+	assert.Nil(t, state.AddBalance(bob, 1))
+	assert.Equal(t, uint64(30), bc.BalanceOf(bob))
+	_, err = bc.StateAt(2)
+	assert.NotNil(t, err)
+}
+
+func TestBlockchain_SetStateHistory(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	aliceAddr := alice.PublicKey().Address()
+	bob := randomAddress()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{aliceAddr: 100})
+	bc.SetStateHistory(2)
+	simulate := func(height uint32) error {
+		tx := NewTransferTransaction(bob, 1)
+		tx.Nonce = uint64(height)
+		tx.GasLimit = TxGas
+		_, err := bc.SimulateAt(aliceAddr, tx, height)
+		return err
+	}
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		assert.Nil(t, bc.AddBlock(nextBlock(t, bc, signedTransfer(t, alice, nonce, bob, 10))))
+	}
+
+	// 只保留高度 2 和 3 的状态
+	for height := uint32(0); height < 2; height++ {
+		_, err := bc.StateAt(height)
+		assert.NotNil(t, err)
+		assert.NotNil(t, simulate(height))
+	}
+	assert.Nil(t, simulate(2))
+	assert.Nil(t, simulate(3))
+	state, err := bc.StateAt(2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(20), state.Balance(bob))
+	state, err = bc.StateAt(3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(30), state.Balance(bob))
+	assert.Equal(t, uint64(30), bc.BalanceOf(bob))
+
+	// 缩小保留范围立即丢弃更早的状态
+	bc.SetStateHistory(0)
+	_, err = bc.StateAt(2)
+	assert.NotNil(t, err)
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc)))
+	_, err = bc.StateAt(3)
+	assert.NotNil(t, err)
+	state, err = bc.StateAt(4)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(30), state.Balance(bob))
+}
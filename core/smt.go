@@ -0,0 +1,194 @@
+package core
+
+import (
+	"MyChain/types"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+)
+
+const (
+	smtLeafPrefix  byte = 0x00
+	smtInnerPrefix byte = 0x01
+	// smtDepth 是稀疏默克尔树的最大深度，即键的位数
+	smtDepth = len(types.Hash{}) * 8
+)
+
+// SparseMerkleTree 是以 256 位键为索引的稀疏默克尔树，键对应的值以其哈希的形式存储。
+// 空子树的哈希为零哈希，只包含一个叶子的子树的哈希直接等于该叶子的哈希，
+// 其余子树的哈希为 sha256(0x01 || 左子树哈希 || 右子树哈希)，
+// 叶子的哈希为 sha256(0x00 || 键 || 值哈希)。
+type SparseMerkleTree struct {
+	leaves map[types.Hash]types.Hash
+}
+
+type smtLeaf struct {
+	key   types.Hash
+	value types.Hash
+}
+
+func NewSparseMerkleTree() *SparseMerkleTree {
+	return &SparseMerkleTree{
+		leaves: make(map[types.Hash]types.Hash),
+	}
+}
+
+// Set 设置键对应的值哈希，值哈希为零哈希时删除该键。
+func (t *SparseMerkleTree) Set(key, value types.Hash) {
+	if value.IsZero() {
+		delete(t.leaves, key)
+		return
+	}
+	t.leaves[key] = value
+}
+
+// Get 返回键对应的值哈希，键不存在时返回零哈希。
+func (t *SparseMerkleTree) Get(key types.Hash) types.Hash {
+	return t.leaves[key]
+}
+
+// Root 返回树的根哈希，空树的根哈希为零哈希。
+func (t *SparseMerkleTree) Root() types.Hash {
+	return smtRoot(t.sortedLeaves(), 0)
+}
+
+// Prove 生成键的默克尔证明。键存在时为包含证明，否则为不存在证明。
+//
+// 参数:
+//
+//	key - 需要证明的键。
+//
+// 返回值:
+//
+//	*MerkleProof - 从根到键所在路径上的兄弟节点哈希，以及路径终点处的叶子（如果有）。
+func (t *SparseMerkleTree) Prove(key types.Hash) *MerkleProof {
+	leaves := t.sortedLeaves()
+	proof := &MerkleProof{}
+	for depth := 0; len(leaves) > 1; depth++ {
+		left, right := smtSplit(leaves, depth)
+		if smtBit(key, depth) == 0 {
+			proof.Siblings = append(proof.Siblings, smtRoot(right, depth+1))
+			leaves = left
+		} else {
+			proof.Siblings = append(proof.Siblings, smtRoot(left, depth+1))
+			leaves = right
+		}
+	}
+	if len(leaves) == 1 {
+		proof.LeafKey = leaves[0].key
+		proof.LeafValue = leaves[0].value
+	}
+	return proof
+}
+
+func (t *SparseMerkleTree) sortedLeaves() []smtLeaf {
+	leaves := make([]smtLeaf, 0, len(t.leaves))
+	for key, value := range t.leaves {
+		leaves = append(leaves, smtLeaf{key: key, value: value})
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i].key[:], leaves[j].key[:]) < 0
+	})
+	return leaves
+}
+
+// MerkleProof 是稀疏默克尔树中某个键的包含证明或不存在证明。
+type MerkleProof struct {
+	// Siblings 是从根开始，沿键的路径向下每一层的兄弟子树哈希
+	Siblings []types.Hash
+	// LeafKey 和 LeafValue 是路径终点处子树中唯一的叶子，LeafValue 为零哈希表示终点是空子树。
+	// 对于不存在证明，该叶子的键与被证明的键不同，但在路径上的前缀相同。
+	LeafKey   types.Hash
+	LeafValue types.Hash
+}
+
+// Verify 验证证明是否表明 key 在根为 root 的树中对应 value。value 为零哈希时验证键不存在。
+//
+// 参数:
+//
+//	root - 树的根哈希。
+//	key - 被证明的键。
+//	value - 期望的值哈希，零哈希表示期望键不存在。
+//
+// 返回值:
+//
+//	error - 证明无效或与期望的值不符时返回错误。
+func (p *MerkleProof) Verify(root, key, value types.Hash) error {
+	if len(p.Siblings) > smtDepth {
+		return fmt.Errorf("merkle proof has %d siblings, at most %d allowed", len(p.Siblings), smtDepth)
+	}
+	var h types.Hash
+	if !p.LeafValue.IsZero() {
+		// 终点处叶子的键必须与被证明的键在路径上的前缀相同
+		for depth := range p.Siblings {
+			if smtBit(p.LeafKey, depth) != smtBit(key, depth) {
+				return fmt.Errorf("merkle proof leaf %s is not on the path of key %s", p.LeafKey, key)
+			}
+		}
+		h = smtLeafHash(p.LeafKey, p.LeafValue)
+	}
+	for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+		if smtBit(key, depth) == 0 {
+			h = smtInnerHash(h, p.Siblings[depth])
+		} else {
+			h = smtInnerHash(p.Siblings[depth], h)
+		}
+	}
+	if h != root {
+		return fmt.Errorf("merkle proof root mismatch, expected %s, got %s", root, h)
+	}
+
+	found := types.Hash{}
+	if !p.LeafValue.IsZero() && p.LeafKey == key {
+		found = p.LeafValue
+	}
+	if found != value {
+		return fmt.Errorf("merkle proof shows value %s for key %s, expected %s", found, key, value)
+	}
+	return nil
+}
+
+// smtRoot 计算已按键排序、且在前 depth 位上前缀相同的叶子所组成子树的哈希。
+func smtRoot(leaves []smtLeaf, depth int) types.Hash {
+	switch len(leaves) {
+	case 0:
+		return types.Hash{}
+	case 1:
+		return smtLeafHash(leaves[0].key, leaves[0].value)
+	}
+	left, right := smtSplit(leaves, depth)
+	return smtInnerHash(smtRoot(left, depth+1), smtRoot(right, depth+1))
+}
+
+// smtSplit 将已排序的叶子按第 depth 位分为左右两棵子树。
+func smtSplit(leaves []smtLeaf, depth int) ([]smtLeaf, []smtLeaf) {
+	i := sort.Search(len(leaves), func(i int) bool {
+		return smtBit(leaves[i].key, depth) == 1
+	})
+	return leaves[:i], leaves[i:]
+}
+
+// smtBit 返回键从最高位开始的第 depth 位。
+func smtBit(key types.Hash, depth int) byte {
+	return (key[depth/8] >> (7 - depth%8)) & 1
+}
+
+func smtLeafHash(key, value types.Hash) types.Hash {
+	buf := make([]byte, 0, 1+2*len(key))
+	buf = append(buf, smtLeafPrefix)
+	buf = append(buf, key[:]...)
+	buf = append(buf, value[:]...)
+	return sha256.Sum256(buf)
+}
+
+func smtInnerHash(left, right types.Hash) types.Hash {
+	if left.IsZero() && right.IsZero() {
+		return types.Hash{}
+	}
+	buf := make([]byte, 0, 1+2*len(left))
+	buf = append(buf, smtInnerPrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
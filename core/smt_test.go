@@ -0,0 +1,81 @@
+package core
+
+import (
+	"MyChain/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSparseMerkleTree_Root(t *testing.T) {
+	tree := NewSparseMerkleTree()
+	assert.Equal(t, types.Hash{}, tree.Root())
+
+	key, value := types.RandomHash(), types.RandomHash()
+	tree.Set(key, value)
+	assert.Equal(t, smtLeafHash(key, value), tree.Root())
+
+	// 根哈希与插入顺序无关
+	keys := make([]types.Hash, 50)
+	for i := range keys {
+		keys[i] = types.RandomHash()
+		tree.Set(keys[i], types.RandomHash())
+	}
+	other := NewSparseMerkleTree()
+	for i := len(keys) - 1; i >= 0; i-- {
+		other.Set(keys[i], tree.Get(keys[i]))
+	}
+	other.Set(key, value)
+	assert.Equal(t, tree.Root(), other.Root())
+
+	// 修改或删除任意键都会改变根哈希
+	root := tree.Root()
+	tree.Set(keys[0], types.RandomHash())
+	assert.NotEqual(t, root, tree.Root())
+	tree.Set(keys[0], types.Hash{})
+	assert.NotEqual(t, root, tree.Root())
+}
+
+func TestSparseMerkleTree_Prove(t *testing.T) {
+	tree := NewSparseMerkleTree()
+	keys := make([]types.Hash, 100)
+	for i := range keys {
+		keys[i] = types.RandomHash()
+		tree.Set(keys[i], types.RandomHash())
+	}
+	root := tree.Root()
+
+	for _, key := range keys {
+		proof := tree.Prove(key)
+		assert.Nil(t, proof.Verify(root, key, tree.Get(key)))
+		assert.NotNil(t, proof.Verify(root, key, types.Hash{}))
+		assert.NotNil(t, proof.Verify(root, key, types.RandomHash()))
+		assert.NotNil(t, proof.Verify(types.RandomHash(), key, tree.Get(key)))
+	}
+
+	// 不存在证明
+	for i := 0; i < 100; i++ {
+		key := types.RandomHash()
+		proof := tree.Prove(key)
+		assert.Nil(t, proof.Verify(root, key, types.Hash{}))
+		assert.NotNil(t, proof.Verify(root, key, types.RandomHash()))
+	}
+
+	// 篡改兄弟节点
+	proof := tree.Prove(keys[0])
+	proof.Siblings[0] = types.RandomHash()
+	assert.NotNil(t, proof.Verify(root, keys[0], tree.Get(keys[0])))
+}
+
+func TestSparseMerkleTree_Prove_Empty(t *testing.T) {
+	tree := NewSparseMerkleTree()
+	key := types.RandomHash()
+	assert.Nil(t, tree.Prove(key).Verify(tree.Root(), key, types.Hash{}))
+
+	// 只有一个叶子时，其他键的不存在证明就是该叶子本身
+	other := types.RandomHash()
+	tree.Set(other, types.RandomHash())
+	proof := tree.Prove(key)
+	assert.Empty(t, proof.Siblings)
+	assert.Nil(t, proof.Verify(tree.Root(), key, types.Hash{}))
+	assert.Nil(t, tree.Prove(other).Verify(tree.Root(), other, tree.Get(other)))
+}
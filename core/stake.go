@@ -0,0 +1,117 @@
+package core
+
+import (
+	"MyChain/types"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// StakingAddress 是保管所有质押金额的系统账户地址，即 sha256("staking") 的后 20 字节。
+// 每个地址的质押金额记录在该账户的存储中，该账户没有代码，也没有对应的私钥。
+var StakingAddress = func() types.Address {
+	hash := sha256.Sum256([]byte("staking"))
+	return types.MustAddressFromBytes(hash[len(hash)-20:])
+}()
+
+// Stake 返回地址当前质押的金额。
+func (s *State) Stake(addr types.Address) uint64 {
+	value := s.GetStorage(StakingAddress, stakeKey(addr))
+	return binary.BigEndian.Uint64(value[len(value)-8:])
+}
+
+// setStake 将地址的质押金额记录到 StakingAddress 的存储中，金额为 0 时删除记录。
+func (s *State) setStake(addr types.Address, amount uint64) {
+	var value types.Hash
+	binary.BigEndian.PutUint64(value[len(value)-8:], amount)
+	s.SetStorage(StakingAddress, stakeKey(addr), value)
+}
+
+// stakeKey 返回地址的质押金额在 StakingAddress 存储中的键，即左侧补零到 32 字节的地址。
+func stakeKey(addr types.Address) types.Hash {
+	var key types.Hash
+	copy(key[len(key)-len(addr):], addr.ToSlice())
+	return key
+}
+
+// StakeOf 返回当前状态下地址质押的金额。
+func (bc *Blockchain) StakeOf(addr types.Address) uint64 {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return bc.headState().Stake(addr)
+}
+
+// stakeHandler 处理 TxKindStake 交易：将 Value 从发送者转入 StakingAddress，并增加发送者的质押金额。
+type stakeHandler struct{}
+
+func (stakeHandler) Name() string {
+	return "stake"
+}
+
+func (stakeHandler) Validate(tx *Transaction) error {
+	if tx.Value == 0 {
+		return fmt.Errorf("stake transaction has no value")
+	}
+	if tx.To != (types.Address{}) {
+		return fmt.Errorf("stake transaction has recipient %s", tx.To)
+	}
+	if len(tx.Data) > 0 {
+		return fmt.Errorf("stake transaction carries %d bytes of data", len(tx.Data))
+	}
+	return nil
+}
+
+func (stakeHandler) IntrinsicGas(tx *Transaction) uint64 {
+	return BaseIntrinsicGas(tx)
+}
+
+func (stakeHandler) Apply(env *TxEnv, gas uint64) (uint64, error) {
+	stake := env.State.Stake(env.From)
+	if err := env.transfer(env.From, StakingAddress, env.Tx.Value); err != nil {
+		return 0, err
+	}
+	// 质押金额之和不超过 StakingAddress 的余额，转账成功说明加法不会溢出
+	env.State.setStake(env.From, stake+env.Tx.Value)
+	return gas, nil
+}
+
+// unstakeHandler 处理 TxKindUnstake 交易：减少发送者的质押金额，并将其从 StakingAddress 退还给发送者。
+// 解除质押的金额以 8 字节大端编码放在 Data 中，Value 必须为 0，因为发送者不需要为解除质押支付金额。
+type unstakeHandler struct{}
+
+func (unstakeHandler) Name() string {
+	return "unstake"
+}
+
+func (unstakeHandler) Validate(tx *Transaction) error {
+	if tx.Value > 0 {
+		return fmt.Errorf("unstake transaction transfers value %d", tx.Value)
+	}
+	if tx.To != (types.Address{}) {
+		return fmt.Errorf("unstake transaction has recipient %s", tx.To)
+	}
+	if len(tx.Data) != 8 {
+		return fmt.Errorf("unstake transaction data has %d bytes, should be 8", len(tx.Data))
+	}
+	if binary.BigEndian.Uint64(tx.Data) == 0 {
+		return fmt.Errorf("unstake transaction has no amount")
+	}
+	return nil
+}
+
+func (unstakeHandler) IntrinsicGas(tx *Transaction) uint64 {
+	return BaseIntrinsicGas(tx)
+}
+
+func (unstakeHandler) Apply(env *TxEnv, gas uint64) (uint64, error) {
+	amount := binary.BigEndian.Uint64(env.Tx.Data)
+	stake := env.State.Stake(env.From)
+	if stake < amount {
+		return 0, fmt.Errorf("insufficient stake for %s: have %d, need %d", env.From, stake, amount)
+	}
+	env.State.setStake(env.From, stake-amount)
+	if err := env.transfer(StakingAddress, env.From, amount); err != nil {
+		return 0, err
+	}
+	return gas, nil
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"MyChain/crypto"
+	"MyChain/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func signedTx(t *testing.T, privateKey crypto.PrivateKey, nonce uint64, tx *Transaction) *Transaction {
+	tx.Nonce = nonce
+	assert.Nil(t, tx.Sign(privateKey))
+	return tx
+}
+
+func TestState_ApplyTransaction_Stake(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 100))
+
+	assert.Nil(t, applyTx(s, BlockContext{}, signedTx(t, privateKey, 0, NewStakeTransaction(60))))
+	assert.Equal(t, uint64(60), s.Stake(from))
+	assert.Equal(t, uint64(40), s.Balance(from))
+	assert.Equal(t, uint64(60), s.Balance(StakingAddress))
+
+	// 解除质押不要求发送者余额中有该金额
+	result, err := s.ApplyTransaction(BlockContext{}, signedTx(t, privateKey, 1, NewUnstakeTransaction(50)))
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Equal(t, uint64(10), s.Stake(from))
+	assert.Equal(t, uint64(90), s.Balance(from))
+
+	// 解除超过质押的金额执行失败，状态不变
+	result, err = s.ApplyTransaction(BlockContext{}, signedTx(t, privateKey, 2, NewUnstakeTransaction(11)))
+	assert.Nil(t, err)
+	assert.True(t, result.Failed())
+	assert.Equal(t, uint64(10), s.Stake(from))
+	assert.Equal(t, uint64(3), s.Nonce(from))
+
+	// 全部解除后删除记录，总供应量不变
+	assert.Nil(t, applyTx(s, BlockContext{}, signedTx(t, privateKey, 3, NewUnstakeTransaction(10))))
+	assert.Equal(t, uint64(0), s.Stake(from))
+	assert.Equal(t, types.Hash{}, s.StorageRoot(StakingAddress))
+	supply, err := s.TotalBalance()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), supply)
+}
+
+func TestStakeHandler_Validate(t *testing.T) {
+	invalid := []*Transaction{
+		NewStakeTransaction(0),
+		{Kind: TxKindStake, Value: 1, To: randomAddress()},
+		{Kind: TxKindStake, Value: 1, Data: []byte{1}},
+		NewUnstakeTransaction(0),
+		{Kind: TxKindUnstake, Value: 1, Data: NewUnstakeTransaction(1).Data},
+		{Kind: TxKindUnstake, To: randomAddress(), Data: NewUnstakeTransaction(1).Data},
+		{Kind: TxKindUnstake, Data: []byte{1}},
+	}
+	for i, tx := range invalid {
+		assert.NotNil(t, tx.Validate(), "transaction %d", i)
+	}
+	assert.Nil(t, NewStakeTransaction(1).Validate())
+	assert.Nil(t, NewUnstakeTransaction(1).Validate())
+	assert.Equal(t, "stake", TxKindStake.String())
+	assert.Equal(t, "unstake", TxKindUnstake.String())
+}
+
+func TestBlockchain_StakeOf(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	bc := newBlockChainWithBalances(t, map[types.Address]uint64{from: 100})
+	assert.Nil(t, bc.AddBlock(nextBlock(t, bc, signedTx(t, privateKey, 0, NewStakeTransaction(30)))))
+	assert.Equal(t, uint64(30), bc.StakeOf(from))
+	assert.Equal(t, uint64(70), bc.BalanceOf(from))
+}
@@ -0,0 +1,581 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// Account 是账户在世界状态中的数据。
+type Account struct {
+	// Balance 是账户余额
+	Balance uint64
+	// Nonce 是账户已发送并被打包的交易数量
+	Nonce uint64
+	// CodeHash 是合约代码的 SHA256 摘要，普通账户为零哈希
+	CodeHash types.Hash
+}
+
+// Bytes 返回账户的确定性编码，用于计算状态根。
+func (a Account) Bytes() []byte {
+	buf := make([]byte, 16, 16+len(a.CodeHash))
+	binary.BigEndian.PutUint64(buf[0:8], a.Balance)
+	binary.BigEndian.PutUint64(buf[8:16], a.Nonce)
+	return append(buf, a.CodeHash[:]...)
+}
+
+// Hash 返回账户编码的 SHA256 摘要，零值账户的哈希为零哈希，即等同于账户不存在。
+func (a Account) Hash() types.Hash {
+	if a == (Account{}) {
+		return types.Hash{}
+	}
+	return sha256.Sum256(a.Bytes())
+}
+
+// IsContract 判断账户是否部署了合约代码。
+func (a Account) IsContract() bool {
+	return !a.CodeHash.IsZero()
+}
+
+// State 是以地址为索引的账户世界状态，包括账户数据、合约代码和合约存储。
+// 每次修改都会记录到日志中，以便通过 Snapshot 和 RevertToSnapshot 撤销。
+type State struct {
+	lock     sync.RWMutex
+	accounts map[types.Address]*Account
+	storage  map[types.Address]map[types.Hash]types.Hash
+	// code 以代码的 SHA256 摘要为索引保存合约代码，代码一经保存不再修改，因此不记录日志
+	code    map[types.Hash][]byte
+	journal []journalEntry
+}
+
+// journalEntry 是一次可撤销的状态修改。
+type journalEntry interface {
+	revert(s *State)
+}
+
+// accountChange 记录账户被修改前的值，prev 为 nil 表示修改前账户不存在。
+type accountChange struct {
+	addr types.Address
+	prev *Account
+}
+
+func (c accountChange) revert(s *State) {
+	if c.prev == nil {
+		delete(s.accounts, c.addr)
+	} else {
+		s.accounts[c.addr] = c.prev
+	}
+}
+
+// storageChange 记录存储槽被修改前的值。
+type storageChange struct {
+	addr types.Address
+	key  types.Hash
+	prev types.Hash
+}
+
+func (c storageChange) revert(s *State) {
+	s.setStorage(c.addr, c.key, c.prev)
+}
+
+func NewState() *State {
+	return &State{
+		accounts: make(map[types.Address]*Account),
+		storage:  make(map[types.Address]map[types.Hash]types.Hash),
+		code:     make(map[types.Hash][]byte),
+	}
+}
+
+// Copy 返回状态的深拷贝，对拷贝的修改不会影响原状态。拷贝不包含修改日志。
+func (s *State) Copy() *State {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	c := NewState()
+	for addr, acc := range s.accounts {
+		copied := *acc
+		c.accounts[addr] = &copied
+	}
+	for addr, slots := range s.storage {
+		copied := make(map[types.Hash]types.Hash, len(slots))
+		for key, value := range slots {
+			copied[key] = value
+		}
+		c.storage[addr] = copied
+	}
+	// 代码不会被修改，拷贝可以与原状态共享
+	for hash, code := range s.code {
+		c.code[hash] = code
+	}
+	return c
+}
+
+// GetCode 返回账户的合约代码，普通账户返回 nil。
+func (s *State) GetCode(addr types.Address) []byte {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	acc, ok := s.accounts[addr]
+	if !ok || !acc.IsContract() {
+		return nil
+	}
+	return s.code[acc.CodeHash]
+}
+
+// SetCode 将 code 设置为账户的合约代码，并更新账户的 CodeHash。
+func (s *State) SetCode(addr types.Address, code []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	hash := types.Hash(sha256.Sum256(code))
+	if _, ok := s.code[hash]; !ok {
+		s.code[hash] = append([]byte(nil), code...)
+	}
+	s.account(addr).CodeHash = hash
+}
+
+// GetStorage 返回账户存储中键对应的值，不存在时返回零哈希。
+func (s *State) GetStorage(addr types.Address, key types.Hash) types.Hash {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.storage[addr][key]
+}
+
+// SetStorage 设置账户存储中键对应的值，值为零哈希时删除该键。
+func (s *State) SetStorage(addr types.Address, key, value types.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.journal = append(s.journal, storageChange{addr: addr, key: key, prev: s.storage[addr][key]})
+	s.setStorage(addr, key, value)
+}
+
+// setStorage 修改存储但不记录日志，调用方需持有写锁。
+func (s *State) setStorage(addr types.Address, key, value types.Hash) {
+	slots := s.storage[addr]
+	if value.IsZero() {
+		delete(slots, key)
+		if len(slots) == 0 {
+			delete(s.storage, addr)
+		}
+		return
+	}
+	if slots == nil {
+		slots = make(map[types.Hash]types.Hash)
+		s.storage[addr] = slots
+	}
+	slots[key] = value
+}
+
+// GetAccount 返回地址对应的账户，账户不存在时返回零值。
+func (s *State) GetAccount(addr types.Address) Account {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if acc, ok := s.accounts[addr]; ok {
+		return *acc
+	}
+	return Account{}
+}
+
+func (s *State) Balance(addr types.Address) uint64 {
+	return s.GetAccount(addr).Balance
+}
+
+func (s *State) Nonce(addr types.Address) uint64 {
+	return s.GetAccount(addr).Nonce
+}
+
+// Root 返回状态根，即以地址的 SHA256 摘要为键的稀疏默克尔树的根哈希，
+// 树中的值由账户哈希和账户的存储根共同决定，见 accountLeaf。零值且存储为空的账户不包含在树中。
+func (s *State) Root() types.Hash {
+	return s.tree().Root()
+}
+
+// StorageRoot 返回账户的存储根，即以存储键的 SHA256 摘要为键、存储值为值的稀疏默克尔树的根哈希。
+// 存储为空时返回零哈希。
+func (s *State) StorageRoot(addr types.Address) types.Hash {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.storageTree(addr).Root()
+}
+
+// Prove 生成地址对应账户的证明，账户不存在时生成不存在证明。
+//
+// 参数:
+//
+//	addr - 账户地址。
+//
+// 返回值:
+//
+//	*AccountProof - 可以针对 Root 返回的状态根验证的账户证明。
+func (s *State) Prove(addr types.Address) *AccountProof {
+	return &AccountProof{
+		Account:     s.GetAccount(addr),
+		StorageRoot: s.StorageRoot(addr),
+		Proof:       s.tree().Prove(accountKey(addr)),
+	}
+}
+
+// ProveStorage 生成账户存储中某个键的证明，键不存在时生成不存在证明。
+//
+// 参数:
+//
+//	addr - 账户地址。
+//	key - 存储键。
+//
+// 返回值:
+//
+//	*StorageProof - 可以针对账户存储根验证的存储证明，存储根本身由 Prove 返回的账户证明证明。
+func (s *State) ProveStorage(addr types.Address, key types.Hash) *StorageProof {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return &StorageProof{
+		Value: s.storage[addr][key],
+		Proof: s.storageTree(addr).Prove(storageKey(key)),
+	}
+}
+
+func (s *State) tree() *SparseMerkleTree {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	t := NewSparseMerkleTree()
+	for addr, acc := range s.accounts {
+		t.Set(accountKey(addr), accountLeaf(*acc, s.storageTree(addr).Root()))
+	}
+	// 存储非空但账户数据为零值的地址同样需要提交
+	for addr := range s.storage {
+		if _, ok := s.accounts[addr]; !ok {
+			t.Set(accountKey(addr), accountLeaf(Account{}, s.storageTree(addr).Root()))
+		}
+	}
+	return t
+}
+
+// storageTree 构造账户的存储树，调用方需持有读锁。
+func (s *State) storageTree(addr types.Address) *SparseMerkleTree {
+	t := NewSparseMerkleTree()
+	for key, value := range s.storage[addr] {
+		t.Set(storageKey(key), value)
+	}
+	return t
+}
+
+// accountKey 返回地址在状态树中的键。
+func accountKey(addr types.Address) types.Hash {
+	return sha256.Sum256(addr.ToSlice())
+}
+
+// storageKey 返回存储键在存储树中的键。
+func storageKey(key types.Hash) types.Hash {
+	return sha256.Sum256(key[:])
+}
+
+// accountLeaf 返回账户在状态树中的值，即 sha256(账户哈希 || 存储根)。
+// 零值且存储为空的账户返回零哈希，即等同于账户不存在。
+func accountLeaf(acc Account, storageRoot types.Hash) types.Hash {
+	accHash := acc.Hash()
+	if accHash.IsZero() && storageRoot.IsZero() {
+		return types.Hash{}
+	}
+	buf := make([]byte, 0, len(accHash)+len(storageRoot))
+	buf = append(buf, accHash[:]...)
+	buf = append(buf, storageRoot[:]...)
+	return sha256.Sum256(buf)
+}
+
+// AccountProof 证明某个账户在给定状态根下的内容，零值账户且存储根为零哈希表示账户不存在。
+type AccountProof struct {
+	Account Account
+	// StorageRoot 是账户的存储根，可用于验证 StorageProof
+	StorageRoot types.Hash
+	Proof       *MerkleProof
+}
+
+// Verify 验证账户证明是否与状态根和地址相符。
+//
+// 参数:
+//
+//	root - 状态根。
+//	addr - 账户地址。
+//
+// 返回值:
+//
+//	error - 证明无效时返回错误。
+func (p *AccountProof) Verify(root types.Hash, addr types.Address) error {
+	if p.Proof == nil {
+		return fmt.Errorf("account proof for %s has no merkle proof", addr)
+	}
+	return p.Proof.Verify(root, accountKey(addr), accountLeaf(p.Account, p.StorageRoot))
+}
+
+// StorageProof 证明账户存储中某个键在给定存储根下的值，零哈希表示键不存在。
+type StorageProof struct {
+	Value types.Hash
+	Proof *MerkleProof
+}
+
+// Verify 验证存储证明是否与存储根和存储键相符。
+//
+// 参数:
+//
+//	storageRoot - 账户的存储根，通常来自已验证的 AccountProof。
+//	key - 存储键。
+//
+// 返回值:
+//
+//	error - 证明无效时返回错误。
+func (p *StorageProof) Verify(storageRoot, key types.Hash) error {
+	if p.Proof == nil {
+		return fmt.Errorf("storage proof for %s has no merkle proof", key)
+	}
+	return p.Proof.Verify(storageRoot, storageKey(key), p.Value)
+}
+
+// TotalBalance 返回所有账户余额之和，超出 uint64 范围时返回错误。
+func (s *State) TotalBalance() (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var total uint64
+	for addr, acc := range s.accounts {
+		if total > math.MaxUint64-acc.Balance {
+			return 0, fmt.Errorf("total balance overflow at %s", addr)
+		}
+		total += acc.Balance
+	}
+	return total, nil
+}
+
+// account 返回地址对应账户的指针用于修改，账户不存在时创建，并记录修改前的值。调用方需持有写锁。
+func (s *State) account(addr types.Address) *Account {
+	acc, ok := s.accounts[addr]
+	if !ok {
+		s.journal = append(s.journal, accountChange{addr: addr})
+		acc = &Account{}
+		s.accounts[addr] = acc
+		return acc
+	}
+	prev := *acc
+	s.journal = append(s.journal, accountChange{addr: addr, prev: &prev})
+	return acc
+}
+
+// Snapshot 返回当前修改日志的位置，可用于 RevertToSnapshot 撤销此后的所有修改。
+func (s *State) Snapshot() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.journal)
+}
+
+// RevertToSnapshot 撤销 Snapshot 返回的位置之后的所有修改。
+func (s *State) RevertToSnapshot(id int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i].revert(s)
+	}
+	s.journal = s.journal[:id]
+}
+
+// clearJournal 丢弃修改日志，此后无法再撤销之前的修改。
+func (s *State) clearJournal() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.journal = nil
+}
+
+// AddBalance 增加账户余额，余额溢出时返回错误。
+func (s *State) AddBalance(addr types.Address, amount uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.accounts[addr] != nil && s.accounts[addr].Balance > math.MaxUint64-amount {
+		return fmt.Errorf("balance overflow for %s", addr)
+	}
+	s.account(addr).Balance += amount
+	return nil
+}
+
+// SubBalance 减少账户余额，余额不足时返回错误且不修改状态。
+func (s *State) SubBalance(addr types.Address, amount uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	balance := uint64(0)
+	if acc, ok := s.accounts[addr]; ok {
+		balance = acc.Balance
+	}
+	if balance < amount {
+		return fmt.Errorf("insufficient balance for %s: have %d, need %d", addr, balance, amount)
+	}
+	s.account(addr).Balance -= amount
+	return nil
+}
+
+// IncrementNonce 将账户的 nonce 加一。
+func (s *State) IncrementNonce(addr types.Address) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.account(addr).Nonce++
+}
+
+// BlockContext 是执行交易时所在区块的信息。
+type BlockContext struct {
+	Height    uint32
+	Timestamp int64
+	// Validator 是打包该区块的验证者地址，gas 价格中超出基础费用的小费支付给该地址
+	Validator types.Address
+	// BaseFee 是区块每单位 gas 的基础费用，交易为这部分支付的手续费被销毁
+	BaseFee uint64
+}
+
+// NewBlockContext 根据区块构造交易执行上下文。
+func NewBlockContext(b *Block) BlockContext {
+	ctx := BlockContext{
+		Height:    b.Height,
+		Timestamp: b.Timestamp,
+		BaseFee:   b.BaseFee,
+	}
+	if b.Validator.Key != nil || len(b.Validator.Ed25519) > 0 {
+		ctx.Validator = b.Validator.Address()
+	}
+	return ctx
+}
+
+// ExecutionResult 是交易被应用到状态上的结果。
+type ExecutionResult struct {
+	// GasUsed 是交易实际消耗的 gas，包括固定消耗
+	GasUsed uint64
+	// ReturnData 是代码通过 RETURN 或 REVERT 返回的数据
+	ReturnData []byte
+	// ContractAddress 是部署合约的交易创建的合约地址，其他交易为零值
+	ContractAddress types.Address
+	// Logs 是交易发出的事件，包括原生货币转账事件和合约代码发出的事件，执行失败时为空
+	Logs []*vm.Log
+	// Err 是部署或代码执行失败的原因，执行成功或交易不执行代码时为 nil
+	Err error
+}
+
+// Failed 判断交易的转账、部署或代码执行是否失败。
+func (r *ExecutionResult) Failed() bool {
+	return r.Err != nil
+}
+
+// ApplyTransaction 将一笔交易应用到状态上：先从发送者余额中预付 GasLimit*GasPrice 并增加发送者的 nonce，
+// 然后将交易分派给其种类注册的 TxHandler 执行状态转换，例如转账、调用合约或部署合约。
+// 执行结束后未使用的 gas 退还给发送者，已使用的 gas 中每单位等于区块基础费用的部分被销毁，
+// 剩余部分作为小费支付给区块验证者。
+// 交易的 Nonce 必须严格等于发送者账户当前的 nonce，并且必须通过其处理器的 Validate 检查。
+// 交易必须已经通过 Verify，以便得到发送者地址。任何检查失败时返回错误，状态保持不变。
+// 处理器执行失败（如代码执行 gas 耗尽、合约地址已被占用）不会使交易无效：处理器产生的所有修改被撤销，
+// 但已消耗的 gas 和 nonce 照常生效，失败原因记录在返回结果中。
+//
+// 参数:
+//
+//	ctx - 交易所在区块的上下文。
+//	tx - 需要应用的交易。
+//
+// 返回值:
+//
+//	*ExecutionResult - 交易的执行结果。
+//	error - 交易未验证、nonce 不匹配、交易种类未注册或校验失败、GasLimit 低于固定消耗、
+//	GasPrice 低于基础费用或余额不足以支付金额和最大手续费时返回错误。
+func (s *State) ApplyTransaction(ctx BlockContext, tx *Transaction) (*ExecutionResult, error) {
+	from := tx.From()
+	if from == (types.Address{}) {
+		return nil, fmt.Errorf("transaction %s has no verified sender", tx.Hash(TxHasher{}))
+	}
+	return s.applyTransaction(ctx, tx, from)
+}
+
+// applyTransaction 以 from 作为发送者应用交易，不检查交易的签名，其余与 ApplyTransaction 相同。
+func (s *State) applyTransaction(ctx BlockContext, tx *Transaction, from types.Address) (*ExecutionResult, error) {
+	if nonce := s.Nonce(from); tx.Nonce != nonce {
+		return nil, fmt.Errorf("invalid nonce for %s, expected %d, got %d", from, nonce, tx.Nonce)
+	}
+	handler, err := tx.Handler()
+	if err != nil {
+		return nil, err
+	}
+	if err := handler.Validate(tx); err != nil {
+		return nil, fmt.Errorf("invalid %s transaction %s: %w", handler.Name(), tx.Hash(TxHasher{}), err)
+	}
+	intrinsicGas := handler.IntrinsicGas(tx)
+	if tx.GasLimit < intrinsicGas {
+		return nil, fmt.Errorf("transaction %s gas limit %d is below intrinsic gas %d", tx.Hash(TxHasher{}), tx.GasLimit, intrinsicGas)
+	}
+	if tx.GasPrice < ctx.BaseFee {
+		return nil, fmt.Errorf("transaction %s gas price %d is below base fee %d", tx.Hash(TxHasher{}), tx.GasPrice, ctx.BaseFee)
+	}
+	// 发送者必须能同时支付转账金额和最大手续费
+	maxFee, ok := mulUint64(tx.GasLimit, tx.GasPrice)
+	if !ok || tx.Value > math.MaxUint64-maxFee {
+		return nil, fmt.Errorf("transaction %s value plus max fee overflows", tx.Hash(TxHasher{}))
+	}
+	if balance := s.Balance(from); balance < tx.Value+maxFee {
+		return nil, fmt.Errorf("insufficient balance for %s: have %d, need %d", from, balance, tx.Value+maxFee)
+	}
+
+	snapshot := s.Snapshot()
+	// 预付最大手续费，执行结束后退还未使用的部分
+	if err := s.SubBalance(from, maxFee); err != nil {
+		s.RevertToSnapshot(snapshot)
+		return nil, err
+	}
+	s.IncrementNonce(from)
+
+	result := &ExecutionResult{}
+	available := tx.GasLimit - intrinsicGas
+	execSnapshot := s.Snapshot()
+	gasLeft, err := handler.Apply(&TxEnv{
+		State:  s,
+		Block:  ctx,
+		Tx:     tx,
+		From:   from,
+		Result: result,
+	}, available)
+	if gasLeft > available {
+		s.RevertToSnapshot(snapshot)
+		return nil, fmt.Errorf("%s handler returned %d gas, more than the %d available", handler.Name(), gasLeft, available)
+	}
+	result.Err = err
+	if result.Err != nil {
+		logrus.WithFields(logrus.Fields{
+			"hash":  tx.Hash(TxHasher{}),
+			"kind":  tx.Kind,
+			"error": result.Err,
+		}).Debugln("transaction execution failed")
+		s.RevertToSnapshot(execSnapshot)
+		// 被撤销的转账和执行不留下事件
+		result.Logs = nil
+	}
+
+	result.GasUsed = tx.GasLimit - gasLeft
+	// 退款和小费都不超过预付的最大手续费，乘法不会溢出
+	if err := s.AddBalance(from, gasLeft*tx.GasPrice); err != nil {
+		s.RevertToSnapshot(snapshot)
+		return nil, err
+	}
+	// 基础费用部分没有支付给任何人，即被销毁
+	if err := s.AddBalance(ctx.Validator, result.GasUsed*(tx.GasPrice-ctx.BaseFee)); err != nil {
+		s.RevertToSnapshot(snapshot)
+		return nil, err
+	}
+	return result, nil
+}
+
+// mulUint64 计算 a*b，溢出时第二个返回值为 false。
+func mulUint64(a, b uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(a, b)
+	return lo, hi == 0
+}
+
+// transfer 从 from 向 to 转账 amount，金额为 0 时不做任何修改。
+func (s *State) transfer(from, to types.Address, amount uint64) error {
+	if amount == 0 {
+		return nil
+	}
+	if err := s.SubBalance(from, amount); err != nil {
+		return err
+	}
+	return s.AddBalance(to, amount)
+}
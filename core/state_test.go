@@ -0,0 +1,344 @@
+package core
+
+import (
+	"MyChain/crypto"
+	"MyChain/types"
+	"MyChain/vm"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func randomAddress() types.Address {
+	return types.MustAddressFromBytes(types.RandomBytes(20))
+}
+
+func signedTransfer(t *testing.T, privateKey crypto.PrivateKey, nonce uint64, to types.Address, value uint64) *Transaction {
+	tx := NewTransferTransaction(to, value)
+	tx.Nonce = nonce
+	assert.Nil(t, tx.Sign(privateKey))
+	return tx
+}
+
+// applyTx 应用交易并只返回错误，便于断言。
+func applyTx(s *State, ctx BlockContext, tx *Transaction) error {
+	_, err := s.ApplyTransaction(ctx, tx)
+	return err
+}
+
+func TestState_Balance(t *testing.T) {
+	s := NewState()
+	addr := randomAddress()
+	assert.Equal(t, uint64(0), s.Balance(addr))
+
+	assert.Nil(t, s.AddBalance(addr, 100))
+	assert.Nil(t, s.SubBalance(addr, 40))
+	assert.Equal(t, uint64(60), s.Balance(addr))
+
+	assert.NotNil(t, s.SubBalance(addr, 61))
+	assert.Equal(t, uint64(60), s.Balance(addr))
+
+	assert.NotNil(t, s.AddBalance(addr, math.MaxUint64))
+	assert.Equal(t, uint64(60), s.Balance(addr))
+}
+
+func TestState_Copy(t *testing.T) {
+	s := NewState()
+	addr := randomAddress()
+	assert.Nil(t, s.AddBalance(addr, 100))
+
+	c := s.Copy()
+	assert.Nil(t, c.SubBalance(addr, 100))
+	c.IncrementNonce(addr)
+
+	assert.Equal(t, Account{Balance: 100}, s.GetAccount(addr))
+	assert.Equal(t, Account{Balance: 0, Nonce: 1}, c.GetAccount(addr))
+}
+
+func TestState_ApplyTransaction(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	to := randomAddress()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 100))
+
+	assert.Nil(t, applyTx(s, BlockContext{}, signedTransfer(t, privateKey, 0, to, 30)))
+	assert.Equal(t, Account{Balance: 70, Nonce: 1}, s.GetAccount(from))
+	assert.Equal(t, uint64(30), s.Balance(to))
+
+	// 透支
+	assert.NotNil(t, applyTx(s, BlockContext{}, signedTransfer(t, privateKey, 1, to, 71)))
+	assert.Equal(t, Account{Balance: 70, Nonce: 1}, s.GetAccount(from))
+
+	// 转账必须有接收者
+	assert.NotNil(t, applyTx(s, BlockContext{}, signedTransfer(t, privateKey, 1, types.Address{}, 1)))
+
+	// 未签名的交易没有发送者
+	assert.NotNil(t, applyTx(s, BlockContext{}, NewTransferTransaction(to, 1)))
+}
+
+func TestState_ApplyTransaction_Nonce(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	to := randomAddress()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 100))
+
+	tx := signedTransfer(t, privateKey, 0, to, 10)
+	assert.Nil(t, applyTx(s, BlockContext{}, tx))
+	// 重放同一笔交易
+	assert.NotNil(t, applyTx(s, BlockContext{}, tx))
+	// 跳过 nonce
+	assert.NotNil(t, applyTx(s, BlockContext{}, signedTransfer(t, privateKey, 2, to, 10)))
+	assert.Nil(t, applyTx(s, BlockContext{}, signedTransfer(t, privateKey, 1, to, 10)))
+	assert.Equal(t, Account{Balance: 80, Nonce: 2}, s.GetAccount(from))
+}
+
+func TestState_ApplyTransaction_Fee(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	to := randomAddress()
+	ctx := BlockContext{Validator: randomAddress()}
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 100000))
+
+	tx := NewTransferTransaction(to, 50)
+	tx.GasPrice = 2
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(ctx, tx)
+	assert.Nil(t, err)
+	assert.Equal(t, TxGas, result.GasUsed)
+	assert.False(t, result.Failed())
+	assert.Equal(t, Account{Balance: 100000 - 50 - 2*TxGas, Nonce: 1}, s.GetAccount(from))
+	assert.Equal(t, uint64(50), s.Balance(to))
+	assert.Equal(t, 2*TxGas, s.Balance(ctx.Validator))
+
+	// 余额足够转账但不足以同时支付最大手续费
+	tx = NewTransferTransaction(to, 40000)
+	tx.Nonce = 1
+	tx.GasPrice = 1
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, ctx, tx))
+	assert.Equal(t, Account{Balance: 100000 - 50 - 2*TxGas, Nonce: 1}, s.GetAccount(from))
+
+	// GasLimit 低于固定消耗
+	tx = NewTransferTransaction(to, 1)
+	tx.Nonce = 1
+	tx.GasLimit = TxGas - 1
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, ctx, tx))
+}
+
+func TestState_RevertToSnapshot(t *testing.T) {
+	s := NewState()
+	existing, created := randomAddress(), randomAddress()
+	assert.Nil(t, s.AddBalance(existing, 100))
+
+	snapshot := s.Snapshot()
+	assert.Nil(t, s.SubBalance(existing, 30))
+	assert.Nil(t, s.AddBalance(created, 30))
+	s.IncrementNonce(existing)
+	s.RevertToSnapshot(snapshot)
+
+	assert.Equal(t, Account{Balance: 100}, s.GetAccount(existing))
+	_, ok := s.accounts[created]
+	assert.False(t, ok)
+}
+
+func TestState_ApplyTransaction_BaseFee(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	to := randomAddress()
+	ctx := BlockContext{Validator: randomAddress(), BaseFee: 7}
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 1000000))
+
+	// gas 价格低于基础费用
+	tx := NewTransferTransaction(to, 10)
+	tx.GasPrice = 6
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, ctx, tx))
+
+	tx.GasPrice = 10
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.Nil(t, applyTx(s, ctx, tx))
+	assert.Equal(t, 1000000-10-10*TxGas, s.Balance(from))
+	assert.Equal(t, uint64(10), s.Balance(to))
+	// 基础费用被销毁，只有小费支付给验证者
+	assert.Equal(t, 3*TxGas, s.Balance(ctx.Validator))
+	total, err := s.TotalBalance()
+	assert.Nil(t, err)
+	assert.Equal(t, 1000000-7*TxGas, total)
+}
+
+func TestState_Prove(t *testing.T) {
+	s := NewState()
+	addr := randomAddress()
+	assert.Nil(t, s.AddBalance(addr, 100))
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, s.AddBalance(randomAddress(), uint64(i+1)))
+	}
+	root := s.Root()
+
+	proof := s.Prove(addr)
+	assert.Equal(t, Account{Balance: 100}, proof.Account)
+	assert.Nil(t, proof.Verify(root, addr))
+	// 伪造余额
+	proof.Account.Balance = 1000
+	assert.NotNil(t, proof.Verify(root, addr))
+
+	absent := randomAddress()
+	proof = s.Prove(absent)
+	assert.Equal(t, Account{}, proof.Account)
+	assert.Nil(t, proof.Verify(root, absent))
+	// 对存在的账户不能给出不存在证明
+	assert.NotNil(t, (&AccountProof{Proof: s.Prove(addr).Proof}).Verify(root, addr))
+
+	// 零值账户等同于不存在
+	s.IncrementNonce(absent)
+	assert.NotEqual(t, root, s.Root())
+	c := NewState()
+	assert.Nil(t, c.AddBalance(randomAddress(), 0))
+	assert.Equal(t, types.Hash{}, c.Root())
+}
+
+// deployContract 由 privateKey 对应的账户以 nonce 部署 code，返回合约地址。
+func deployContract(t *testing.T, s *State, privateKey crypto.PrivateKey, nonce uint64, code []byte) types.Address {
+	tx := NewDeployTransaction(code)
+	tx.Nonce = nonce
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.Nil(t, result.Err)
+	return result.ContractAddress
+}
+
+func TestState_ApplyTransaction_Execute(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 1000000))
+	key := types.Hash{31: 1}
+
+	// PUSH1 0x2a PUSH1 0x01 SSTORE
+	contract := deployContract(t, s, privateKey, 0, []byte{byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x01, byte(vm.SSTORE)})
+	tx := NewCallTransaction(contract, nil, TxGas+2*vm.GasFastest+vm.GasSStoreSet)
+	tx.Nonce = 1
+	tx.Value = 10
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Equal(t, tx.GasLimit, result.GasUsed)
+	assert.Equal(t, types.Hash{31: 0x2a}, s.GetStorage(contract, key))
+	assert.Equal(t, types.Hash{}, s.GetStorage(from, key))
+	assert.Equal(t, uint64(10), s.Balance(contract))
+
+	// 执行失败时转账和存储修改被撤销，但所有 gas 和 nonce 照常生效
+	// PUSH1 0x07 PUSH1 0x01 SSTORE ADD
+	failing := deployContract(t, s, privateKey, 2, []byte{byte(vm.PUSH1), 0x07, byte(vm.PUSH1), 0x01, byte(vm.SSTORE), byte(vm.ADD)})
+	ctx := BlockContext{Validator: randomAddress()}
+	tx = NewCallTransaction(failing, nil, TxGas+100000)
+	tx.Nonce = 3
+	tx.Value = 10
+	tx.GasPrice = 1
+	assert.Nil(t, tx.Sign(privateKey))
+	balance := s.Balance(from)
+	result, err = s.ApplyTransaction(ctx, tx)
+	assert.Nil(t, err)
+	assert.True(t, result.Failed())
+	assert.Equal(t, tx.GasLimit, result.GasUsed)
+	assert.Equal(t, types.Hash{}, s.GetStorage(failing, key))
+	assert.Equal(t, uint64(0), s.Balance(failing))
+	assert.Equal(t, balance-tx.GasLimit, s.Balance(from))
+	assert.Equal(t, uint64(4), s.Nonce(from))
+	assert.Equal(t, tx.GasLimit, s.Balance(ctx.Validator))
+
+	// 调用普通账户时 Data 不会被执行
+	tx = NewCallTransaction(randomAddress(), []byte{byte(vm.ADD)}, 100000)
+	tx.Nonce = 4
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err = s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Equal(t, IntrinsicGas(tx), result.GasUsed)
+}
+
+func TestState_ApplyTransaction_OutOfGas(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 1000000))
+	contract := deployContract(t, s, privateKey, 0, []byte{byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x01, byte(vm.SSTORE)})
+	balance := s.Balance(from)
+
+	// SSTORE 需要的 gas 不足
+	tx := NewCallTransaction(contract, nil, TxGas+vm.GasSStoreSet)
+	tx.Nonce = 1
+	tx.GasPrice = 2
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(result.Err, vm.ErrOutOfGas))
+	assert.Equal(t, tx.GasLimit, result.GasUsed)
+	assert.Equal(t, types.Hash{}, s.GetStorage(contract, types.Hash{31: 1}))
+	spent := 2 * tx.GasLimit
+	assert.Equal(t, balance-spent, s.Balance(from))
+
+	// 未使用的 gas 退还给发送者
+	tx = NewCallTransaction(contract, nil, 100000)
+	tx.Nonce = 2
+	tx.GasPrice = 2
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err = s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.Equal(t, TxGas+2*vm.GasFastest+vm.GasSStoreSet, result.GasUsed)
+	assert.Equal(t, balance-spent-2*result.GasUsed, s.Balance(from))
+}
+
+func TestState_Storage(t *testing.T) {
+	s := NewState()
+	addr := randomAddress()
+	key, value := types.RandomHash(), types.RandomHash()
+
+	snapshot := s.Snapshot()
+	s.SetStorage(addr, key, value)
+	c := s.Copy()
+	assert.Equal(t, value, c.GetStorage(addr, key))
+	s.RevertToSnapshot(snapshot)
+	assert.Equal(t, types.Hash{}, s.GetStorage(addr, key))
+	assert.Equal(t, value, c.GetStorage(addr, key))
+}
+
+func TestState_ProveStorage(t *testing.T) {
+	s := NewState()
+	addr := randomAddress()
+	s.SetCode(addr, []byte{byte(vm.STOP)})
+	key, value := types.RandomHash(), types.RandomHash()
+	s.SetStorage(addr, key, value)
+	root := s.Root()
+
+	accountProof := s.Prove(addr)
+	assert.Nil(t, accountProof.Verify(root, addr))
+	assert.Equal(t, s.StorageRoot(addr), accountProof.StorageRoot)
+	proof := s.ProveStorage(addr, key)
+	assert.Equal(t, value, proof.Value)
+	assert.Nil(t, proof.Verify(accountProof.StorageRoot, key))
+
+	// 不存在的键
+	missing := types.RandomHash()
+	proof = s.ProveStorage(addr, missing)
+	assert.Equal(t, types.Hash{}, proof.Value)
+	assert.Nil(t, proof.Verify(accountProof.StorageRoot, missing))
+	proof.Value = value
+	assert.NotNil(t, proof.Verify(accountProof.StorageRoot, missing))
+
+	// 伪造的存储根无法通过账户证明
+	accountProof.StorageRoot = types.RandomHash()
+	assert.NotNil(t, accountProof.Verify(root, addr))
+
+	// 存储的修改改变状态根
+	s.SetStorage(addr, key, types.Hash{})
+	assert.NotEqual(t, root, s.Root())
+}
@@ -3,24 +3,178 @@ package core
 import (
 	"MyChain/crypto"
 	"MyChain/types"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"slices"
 )
 
+// TxKind 是交易种类的标签，决定由哪个 TxHandler 校验和执行交易。
+type TxKind byte
+
+const (
+	// TxKindCall 向 To 转账 Value，如果 To 是合约则以 Data 为输入执行合约代码，否则 Data 只是附带的数据
+	TxKindCall TxKind = iota
+	// TxKindDeploy 将 Data 作为代码部署为新合约，合约地址由发送者地址和交易 Nonce 决定，Value 转入新合约
+	TxKindDeploy
+	// TxKindTransfer 向 To 转账 Value，不携带数据，也不执行任何代码
+	TxKindTransfer
+	// TxKindStake 质押 Value，金额转入 StakingAddress，不能有接收者或数据
+	TxKindStake
+	// TxKindUnstake 解除质押 Data 中以 8 字节大端编码的金额，金额从 StakingAddress 退还给发送者，不能有接收者或 Value
+	TxKindUnstake
+)
+
+// String 返回交易种类注册的处理器名称。
+func (k TxKind) String() string {
+	if h, ok := LookupTxHandler(k); ok {
+		return h.Name()
+	}
+	return fmt.Sprintf("unknown(%d)", byte(k))
+}
+
 type Transaction struct {
-	Data      []byte
-	From      crypto.PublicKey
+	// Kind 是交易的种类，零值为 TxKindCall
+	Kind TxKind
+	// Nonce 是发送者账户的交易序号，必须与账户当前的 nonce 相等，用于防止交易重放
+	Nonce uint64
+	// To 是转账或调用的接收者，部署合约时为零值，Value 是转账金额
+	To    types.Address
+	Value uint64
+	// GasLimit 是交易最多可以消耗的 gas，GasPrice 是每单位 gas 愿意支付的价格
+	GasLimit uint64
+	GasPrice uint64
+	Data     []byte
+
 	Signature *crypto.Signature
+	// Multisig 不为空时交易由该多签账户发送，Signatures 是成员的签名，此时 Signature 必须为空
+	Multisig   *crypto.MultisigAccount
+	Signatures []*crypto.Signature
 
 	//cached
 	hash types.Hash
+	//from is the sender address recovered from the signature
+	from types.Address
 	//firstSeen is the timestamp when the transaction is first seen locally
 	firstSeen int64
 }
 
+// NewTransaction 创建一笔携带 data 的交易，GasLimit 默认为交易的固定消耗。
 func NewTransaction(data []byte) *Transaction {
-	return &Transaction{
+	tx := &Transaction{
 		Data: data,
 	}
+	tx.GasLimit = IntrinsicGas(tx)
+	return tx
+}
+
+// NewTransferTransaction 创建一笔向 to 转账 value 的 TxKindTransfer 交易，GasLimit 默认为 TxGas。
+func NewTransferTransaction(to types.Address, value uint64) *Transaction {
+	return &Transaction{
+		Kind:     TxKindTransfer,
+		To:       to,
+		Value:    value,
+		GasLimit: TxGas,
+	}
+}
+
+// NewStakeTransaction 创建一笔质押 value 的 TxKindStake 交易，GasLimit 默认为 TxGas。
+func NewStakeTransaction(value uint64) *Transaction {
+	return &Transaction{
+		Kind:     TxKindStake,
+		Value:    value,
+		GasLimit: TxGas,
+	}
+}
+
+// NewUnstakeTransaction 创建一笔解除质押 amount 的 TxKindUnstake 交易，GasLimit 默认为交易的固定消耗。
+func NewUnstakeTransaction(amount uint64) *Transaction {
+	tx := &Transaction{
+		Kind: TxKindUnstake,
+		Data: binary.BigEndian.AppendUint64(nil, amount),
+	}
+	tx.GasLimit = IntrinsicGas(tx)
+	return tx
+}
+
+// NewDeployTransaction 创建一笔部署合约的交易，GasLimit 默认为交易的固定消耗加上保存代码的消耗。
+func NewDeployTransaction(code []byte) *Transaction {
+	tx := &Transaction{
+		Kind: TxKindDeploy,
+		Data: code,
+	}
+	tx.GasLimit = IntrinsicGas(tx) + uint64(len(code))*CodeDepositGas
+	return tx
+}
+
+// NewCallTransaction 创建一笔以 input 为输入调用合约 to 的交易，GasLimit 需要根据合约代码设置。
+func NewCallTransaction(to types.Address, input []byte, gasLimit uint64) *Transaction {
+	return &Transaction{
+		To:       to,
+		Data:     input,
+		GasLimit: gasLimit,
+	}
+}
+
+// Handler 返回交易种类对应的处理器，种类未注册时返回错误。
+func (tx *Transaction) Handler() (TxHandler, error) {
+	h, ok := LookupTxHandler(tx.Kind)
+	if !ok {
+		return nil, fmt.Errorf("transaction has unknown kind %s", tx.Kind)
+	}
+	return h, nil
+}
+
+// Validate 将交易分派给其种类的处理器，检查交易的字段是否符合该种类的要求，
+// 并检查多签账户是否有效、已有的签名是否为规范的可恢复形式。
+func (tx *Transaction) Validate() error {
+	h, err := tx.Handler()
+	if err != nil {
+		return err
+	}
+	if err := h.Validate(tx); err != nil {
+		return fmt.Errorf("invalid %s transaction: %w", h.Name(), err)
+	}
+	// 交易哈希包含多签账户地址和签名的序列化，无效的账户和格式错误的签名必须在计算哈希之前被拒绝
+	if tx.Multisig != nil {
+		if err := tx.Multisig.Validate(); err != nil {
+			return fmt.Errorf("invalid multisig account: %w", err)
+		}
+	}
+	if tx.Signature != nil {
+		if err := tx.Signature.ValidateRecoverable(); err != nil {
+			return fmt.Errorf("invalid transaction signature: %w", err)
+		}
+	}
+	for i, sig := range tx.Signatures {
+		if err := sig.ValidateRecoverable(); err != nil {
+			return fmt.Errorf("invalid multisig signature at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// SigningBytes 返回交易中需要签名的内容的确定性编码，不包括签名本身。
+// 多签交易在末尾追加多签账户的地址，使成员的签名只对该账户有效。
+func (tx *Transaction) SigningBytes() []byte {
+	b := make([]byte, 0, 1+8+20+8+8+8+4+len(tx.Data))
+	b = append(b, byte(tx.Kind))
+	b = binary.BigEndian.AppendUint64(b, tx.Nonce)
+	b = append(b, tx.To.ToSlice()...)
+	b = binary.BigEndian.AppendUint64(b, tx.Value)
+	b = binary.BigEndian.AppendUint64(b, tx.GasLimit)
+	b = binary.BigEndian.AppendUint64(b, tx.GasPrice)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(tx.Data)))
+	b = append(b, tx.Data...)
+	if tx.Multisig != nil {
+		b = append(b, tx.Multisig.Address().ToSlice()...)
+	}
+	return b
+}
+
+// SigningHash 返回 SigningBytes 的 SHA256 摘要，签名和公钥恢复都基于该摘要。
+func (tx *Transaction) SigningHash() types.Hash {
+	return types.Hash(sha256.Sum256(tx.SigningBytes()))
 }
 
 func (tx *Transaction) Hash(hasher Hasher[*Transaction]) types.Hash {
@@ -38,38 +192,129 @@ func (tx *Transaction) Hash(hasher Hasher[*Transaction]) types.Hash {
 // 返回值:
 // - error: 执行过程中遇到的错误，如果签名成功则为nil。
 func (tx *Transaction) Sign(privateKey crypto.PrivateKey) error {
-	// 使用私钥对交易数据进行签名
-	sign, err := privateKey.Sign(tx.Data)
+	// 使用私钥对交易内容的摘要进行签名
+	sign, err := privateKey.Sign(tx.SigningHash().ToSlice())
 	if err != nil {
 		return err // 返回签名过程中遇到的任何错误
 	}
 
-	// 设置公钥和签名值
-	tx.From = privateKey.PublicKey()
+	// 设置签名值，发送者地址由签名恢复得到，无需携带公钥
 	tx.Signature = sign
+	tx.from = privateKey.PublicKey().Address()
+	// 交易哈希包含签名，需要重新计算
+	tx.hash = types.Hash{}
 
 	return nil // 成功完成签名过程，返回nil
 }
 
-// Verify 验证交易的签名有效性。
+// SignMultisig 以多签账户 account 的成员身份对交易签名，并将签名按签名者在成员中的顺序插入 Signatures。
+// 每个成员各自调用一次，签名数量达到阈值后交易即可通过 Verify，之后不再接受新的签名。
+//
+// 参数:
+// - account: 发送交易的多签账户，同一交易的所有签名必须针对同一个账户。
+// - privateKey: 成员的私钥。
+//
+// 返回值:
+// - error: 签名者不是成员、已经签过名、签名数量已达到阈值、交易已属于其他账户或已有单签签名时返回错误。
+func (tx *Transaction) SignMultisig(account *crypto.MultisigAccount, privateKey crypto.PrivateKey) error {
+	if tx.Signature != nil {
+		return fmt.Errorf("transaction already has a single signature")
+	}
+	if err := account.Validate(); err != nil {
+		return err
+	}
+	if tx.Multisig != nil && tx.Multisig.Address() != account.Address() {
+		return fmt.Errorf("transaction belongs to multisig %s, not %s", tx.Multisig.Address(), account.Address())
+	}
+	if len(tx.Signatures) >= int(account.Threshold) {
+		return fmt.Errorf("multisig %s already has %d signatures", account.Address(), len(tx.Signatures))
+	}
+	pubKey := privateKey.PublicKey()
+	if !account.IsMember(pubKey) {
+		return fmt.Errorf("signer %s is not a member of multisig %s", pubKey.Address(), account.Address())
+	}
+	tx.Multisig = account
+	digest := tx.SigningHash().ToSlice()
+	sig, err := privateKey.Sign(digest)
+	if err != nil {
+		return err
+	}
+	index, err := account.SignerIndex(digest, sig)
+	if err != nil {
+		return err
+	}
+	// 找到按成员顺序的插入位置
+	pos := len(tx.Signatures)
+	for i, other := range tx.Signatures {
+		j, err := account.SignerIndex(digest, other)
+		if err != nil {
+			return fmt.Errorf("invalid signature at index %d: %w", i, err)
+		}
+		if j == index {
+			return fmt.Errorf("signer %s has already signed", pubKey.Address())
+		}
+		if j > index {
+			pos = i
+			break
+		}
+	}
+	tx.Signatures = slices.Insert(tx.Signatures, pos, sig)
+	// 发送者需要在签名数量达到阈值后由 Verify 确定，交易哈希包含签名，需要重新计算
+	tx.from = types.Address{}
+	tx.hash = types.Hash{}
+	return nil
+}
+
+// Verify 验证交易的签名有效性，并从签名中恢复发送者地址。
 // 如果交易签名为空，返回一个错误。
-// 如果签名无法通过公钥和交易数据验证，返回一个错误。
-// 若验证成功，返回 nil。
+// 如果无法从签名和交易数据恢复出有效的公钥，返回一个错误。
+// 多签交易要求 Signatures 恰好是多签账户 Threshold 个不同成员按成员顺序排列的签名，发送者为多签账户的地址。
+// 若验证成功，返回 nil，此后可通过 From 获取发送者地址。
 func (tx *Transaction) Verify() error {
+	if tx.Multisig != nil {
+		return tx.verifyMultisig()
+	}
+	if len(tx.Signatures) > 0 {
+		return fmt.Errorf("transaction has multisig signatures but no multisig account")
+	}
 	// 检查交易签名是否为空
 	if tx.Signature == nil {
 		return fmt.Errorf("transaction has no signature")
 	}
 
-	// 验证签名，如果无效则返回错误
-	if !tx.Signature.Verify(tx.From, tx.Data) {
-		return fmt.Errorf("invalid signature")
+	// 从签名恢复公钥，如果无效则返回错误
+	pubKey, err := crypto.RecoverPublicKey(tx.SigningHash().ToSlice(), tx.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
 	}
+	tx.from = pubKey.Address()
 
 	// 验证成功，返回 nil
 	return nil
 }
 
+// verifyMultisig 验证多签交易的签名，并将发送者设置为多签账户的地址。
+func (tx *Transaction) verifyMultisig() error {
+	if tx.Signature != nil {
+		return fmt.Errorf("multisig transaction has a single signature")
+	}
+	// SigningHash 需要多签账户的地址，先检查账户是否有效
+	if err := tx.Multisig.Validate(); err != nil {
+		return fmt.Errorf("invalid multisig: %w", err)
+	}
+	if err := tx.Multisig.Verify(tx.SigningHash().ToSlice(), tx.Signatures); err != nil {
+		return fmt.Errorf("invalid multisig: %w", err)
+	}
+	tx.from = tx.Multisig.Address()
+	return nil
+}
+
+// From 返回交易的发送者地址。
+// 该地址在 Sign 或 Verify 时由签名得到，交易未签名或未验证时为零值。
+func (tx *Transaction) From() types.Address {
+	return tx.from
+}
+
 func (tx *Transaction) SetFirstSeen(firstSeen int64) {
 	tx.firstSeen = firstSeen
 }
@@ -2,16 +2,16 @@ package core
 
 import (
 	"MyChain/crypto"
+	"MyChain/types"
 	"bytes"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 )
 
 func randomTxWithSignature(t *testing.T) *Transaction {
 	privateKey := crypto.GeneratePrivateKey()
-	tx := &Transaction{
-		Data: []byte("foo"),
-	}
+	tx := NewTransaction([]byte("foo"))
 	assert.Nil(t, tx.Sign(privateKey))
 	return tx
 }
@@ -35,9 +35,15 @@ func TestTransaction_Verify(t *testing.T) {
 
 	assert.Nil(t, tx.Sign(privateKey))
 	assert.Nil(t, tx.Verify())
+	assert.Equal(t, privateKey.PublicKey().Address(), tx.From())
 
-	otherPrivKey := crypto.GeneratePrivateKey()
-	tx.From = otherPrivKey.PublicKey()
+	// 篡改数据后恢复出的发送者不再是签名者
+	tx.Data = []byte("other")
+	if err := tx.Verify(); err == nil {
+		assert.NotEqual(t, privateKey.PublicKey().Address(), tx.From())
+	}
+
+	tx.Signature = nil
 	assert.NotNil(t, tx.Verify())
 }
 
@@ -48,6 +54,210 @@ func TestTransaction_Encode_Decode(t *testing.T) {
 
 	dec := new(Transaction)
 	assert.Nil(t, dec.Decode(NewGobTxDecoder(&buf)))
+	assert.Equal(t, tx.Data, dec.Data)
+	assert.Equal(t, tx.Signature.RecoverableBytes(), dec.Signature.RecoverableBytes())
 
+	assert.Nil(t, dec.Verify())
 	assert.Equal(t, tx, dec)
 }
+
+func TestTransaction_Decode_MalformedSignature(t *testing.T) {
+	oversized := new(big.Int).Lsh(big.NewInt(1), 300)
+	malformed := []*crypto.Signature{
+		{V: 1},
+		{R: oversized, S: big.NewInt(1)},
+		{R: big.NewInt(1), S: big.NewInt(1), V: 4},
+		{Algo: crypto.AlgoEd25519, Raw: []byte{1}},
+	}
+	for i, sig := range malformed {
+		for _, multisig := range []bool{false, true} {
+			tx := NewTransferTransaction(types.Address{1}, 1)
+			if multisig {
+				tx.Signatures = []*crypto.Signature{sig}
+			} else {
+				tx.Signature = sig
+			}
+			buf := bytes.Buffer{}
+			assert.Nil(t, tx.Encode(NewGobTxEncoder(&buf)))
+
+			dec := new(Transaction)
+			assert.NotNil(t, dec.Decode(NewGobTxDecoder(&buf)), "signature %d", i)
+		}
+	}
+}
+
+func TestTxHasher_MalformedSignature(t *testing.T) {
+	oversized := new(big.Int).Lsh(big.NewInt(1), 300)
+	txs := []*Transaction{
+		{Signature: &crypto.Signature{S: big.NewInt(1)}},
+		{Signature: &crypto.Signature{R: oversized, S: oversized}},
+		{Signatures: []*crypto.Signature{nil}},
+		{Signatures: []*crypto.Signature{{V: 1}, nil}},
+	}
+	for i, tx := range txs {
+		assert.NotPanics(t, func() { tx.Hash(TxHasher{}) }, "transaction %d", i)
+		assert.NotNil(t, tx.Validate(), "transaction %d", i)
+	}
+	// 空的多签签名也参与哈希
+	assert.NotEqual(t, txs[2].Hash(TxHasher{}), (&Transaction{}).Hash(TxHasher{}))
+}
+
+func TestTransaction_Verify_Ed25519(t *testing.T) {
+	privateKey, err := crypto.GenerateKey(crypto.AlgoEd25519)
+	assert.Nil(t, err)
+	tx := &Transaction{
+		Data: []byte("test"),
+	}
+
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.Nil(t, tx.Verify())
+	assert.Equal(t, privateKey.PublicKey().Address(), tx.From())
+
+	tx.Data = []byte("other")
+	assert.NotNil(t, tx.Verify())
+}
+
+func TestTransaction_Sign_CoversTransfer(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	tx := NewTransferTransaction(randomAddress(), 10)
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.Nil(t, tx.Verify())
+	hash := tx.Hash(TxHasher{})
+
+	// 篡改接收者或金额后无法恢复出原发送者，交易哈希也随之改变
+	tx.Value = 1000
+	if err := tx.Verify(); err == nil {
+		assert.NotEqual(t, privateKey.PublicKey().Address(), tx.From())
+	}
+	assert.NotEqual(t, hash, TxHasher{}.Hash(tx))
+}
+
+func TestTransaction_Sign_CoversNonce(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	tx := NewTransferTransaction(randomAddress(), 10)
+	tx.Nonce = 5
+	assert.Nil(t, tx.Sign(privateKey))
+
+	tx.Nonce = 6
+	if err := tx.Verify(); err == nil {
+		assert.NotEqual(t, privateKey.PublicKey().Address(), tx.From())
+	}
+}
+
+func TestTransaction_Sign_CoversGas(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	tx := NewTransferTransaction(randomAddress(), 10)
+	tx.GasPrice = 1
+	assert.Nil(t, tx.Sign(privateKey))
+
+	tx.GasPrice = 2
+	if err := tx.Verify(); err == nil {
+		assert.NotEqual(t, privateKey.PublicKey().Address(), tx.From())
+	}
+	tx.GasPrice = 1
+	tx.GasLimit++
+	if err := tx.Verify(); err == nil {
+		assert.NotEqual(t, privateKey.PublicKey().Address(), tx.From())
+	}
+}
+
+func TestTransaction_Multisig(t *testing.T) {
+	keys := []crypto.PrivateKey{crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey()}
+	account, err := crypto.NewMultisigAccount(2, []crypto.PublicKey{keys[0].PublicKey(), keys[1].PublicKey(), keys[2].PublicKey()})
+	assert.Nil(t, err)
+
+	tx := NewTransferTransaction(randomAddress(), 10)
+	tx.Multisig = account
+	tx.GasLimit = IntrinsicGas(tx)
+	assert.Equal(t, TxGas+3*TxSignatureGas, tx.GasLimit)
+
+	assert.Nil(t, tx.SignMultisig(account, keys[0]))
+	assert.NotNil(t, tx.Verify())
+	assert.NotNil(t, tx.SignMultisig(account, keys[0]))
+	assert.NotNil(t, tx.SignMultisig(account, crypto.GeneratePrivateKey()))
+	hash := tx.Hash(TxHasher{})
+	assert.Nil(t, tx.SignMultisig(account, keys[2]))
+	assert.NotEqual(t, hash, tx.Hash(TxHasher{}))
+	assert.Nil(t, tx.Verify())
+	assert.Equal(t, account.Address(), tx.From())
+	// 达到阈值后不再接受签名
+	assert.NotNil(t, tx.SignMultisig(account, keys[1]))
+
+	// 签名按成员顺序排列，与签名的先后无关；调换顺序或追加签名会改变交易哈希，因此不能通过验证
+	reordered := NewTransferTransaction(tx.To, tx.Value)
+	reordered.GasLimit = tx.GasLimit
+	assert.Nil(t, reordered.SignMultisig(account, keys[2]))
+	assert.Nil(t, reordered.SignMultisig(account, keys[0]))
+	assert.Nil(t, reordered.Verify())
+	digest := tx.SigningHash().ToSlice()
+	for _, sigs := range [][]*crypto.Signature{reordered.Signatures, tx.Signatures} {
+		first, err := account.SignerIndex(digest, sigs[0])
+		assert.Nil(t, err)
+		second, err := account.SignerIndex(digest, sigs[1])
+		assert.Nil(t, err)
+		assert.Less(t, first, second)
+	}
+	reordered.Signatures[0], reordered.Signatures[1] = reordered.Signatures[1], reordered.Signatures[0]
+	assert.NotNil(t, reordered.Verify())
+	extra, err := keys[1].Sign(digest)
+	assert.Nil(t, err)
+	reordered.Signatures = append(tx.Signatures[:2:2], extra)
+	assert.NotNil(t, reordered.Verify())
+
+	// 编码后仍然可以验证
+	buf := bytes.Buffer{}
+	assert.Nil(t, tx.Encode(NewGobTxEncoder(&buf)))
+	dec := new(Transaction)
+	assert.Nil(t, dec.Decode(NewGobTxDecoder(&buf)))
+	assert.Nil(t, dec.Verify())
+	assert.Equal(t, account.Address(), dec.From())
+
+	// 成员的签名不能用于阈值更低的另一个多签账户
+	other, err := crypto.NewMultisigAccount(1, []crypto.PublicKey{keys[0].PublicKey(), keys[2].PublicKey()})
+	assert.Nil(t, err)
+	dec.Multisig = other
+	assert.NotNil(t, dec.Verify())
+	assert.NotNil(t, tx.SignMultisig(other, keys[1]))
+
+	// 不能同时携带单签和多签签名
+	tx.Signature = tx.Signatures[0]
+	assert.NotNil(t, tx.Verify())
+	tx = NewTransferTransaction(randomAddress(), 10)
+	assert.Nil(t, tx.Sign(keys[0]))
+	assert.NotNil(t, tx.SignMultisig(account, keys[1]))
+	tx.Signatures = []*crypto.Signature{tx.Signature}
+	assert.NotNil(t, tx.Verify())
+}
+
+func TestTransaction_Decode_InvalidMultisigMember(t *testing.T) {
+	tx := NewTransferTransaction(randomAddress(), 10)
+	tx.Multisig = &crypto.MultisigAccount{Threshold: 1, Members: []crypto.PublicKey{{}}}
+	buf := bytes.Buffer{}
+	assert.Nil(t, tx.Encode(NewGobTxEncoder(&buf)))
+
+	dec := new(Transaction)
+	assert.NotPanics(t, func() {
+		assert.NotNil(t, dec.Decode(NewGobTxDecoder(&buf)))
+		assert.NotNil(t, dec.Verify())
+	})
+}
+
+func TestState_ApplyTransaction_Multisig(t *testing.T) {
+	keys := []crypto.PrivateKey{crypto.GeneratePrivateKey(), crypto.GeneratePrivateKey()}
+	account, err := crypto.NewMultisigAccount(2, []crypto.PublicKey{keys[0].PublicKey(), keys[1].PublicKey()})
+	assert.Nil(t, err)
+	s := NewState()
+	assert.Nil(t, s.AddBalance(account.Address(), 100))
+	to := randomAddress()
+
+	tx := NewTransferTransaction(to, 30)
+	tx.Multisig = account
+	tx.GasLimit = IntrinsicGas(tx)
+	for _, key := range keys {
+		assert.Nil(t, tx.SignMultisig(account, key))
+	}
+	assert.Nil(t, tx.Verify())
+	assert.Nil(t, applyTx(s, BlockContext{}, tx))
+	assert.Equal(t, Account{Balance: 70, Nonce: 1}, s.GetAccount(account.Address()))
+	assert.Equal(t, uint64(30), s.Balance(to))
+}
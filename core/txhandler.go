@@ -0,0 +1,209 @@
+package core
+
+import (
+	"MyChain/types"
+	"MyChain/vm"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// TxHandler 实现一种交易的校验和状态转换，通过 RegisterTxHandler 按交易种类注册。
+// 手续费的预付、退还和 nonce 的增加由 ApplyTransaction 统一处理，处理器只负责交易本身的效果。
+type TxHandler interface {
+	// Name 返回交易种类的名称
+	Name() string
+	// Validate 检查交易的字段是否符合该种类的要求，只依赖交易本身，不访问状态。
+	// 校验失败的交易是无效的，不能被打包
+	Validate(tx *Transaction) error
+	// IntrinsicGas 返回交易在执行之前固定消耗的 gas
+	IntrinsicGas(tx *Transaction) uint64
+	// Apply 使用给定的 gas 执行交易的状态转换，返回剩余的 gas。
+	// 返回错误表示执行失败，交易仍然有效，ApplyTransaction 会撤销 Apply 产生的所有修改
+	Apply(env *TxEnv, gas uint64) (uint64, error)
+}
+
+// TxEnv 是处理器执行交易状态转换的环境。
+type TxEnv struct {
+	// State 是交易被应用的状态，发送者已经预付了手续费并增加了 nonce
+	State *State
+	// Block 是交易所在区块的上下文
+	Block BlockContext
+	// Tx 是被执行的交易，From 是其发送者
+	Tx   *Transaction
+	From types.Address
+	// Result 是交易的执行结果，处理器可以填写返回数据、事件和合约地址，GasUsed 和 Err 由 ApplyTransaction 填写
+	Result *ExecutionResult
+}
+
+// TransferTopic 是原生货币转账事件的第一个主题，即 sha256("Transfer(address,address,uint64)")。
+var TransferTopic = types.Hash(sha256.Sum256([]byte("Transfer(address,address,uint64)")))
+
+// AddressTopic 返回地址作为事件主题时的编码，即左侧补零到 32 字节的地址，与合约通过 CALLER 等指令得到的地址一致。
+func AddressTopic(addr types.Address) types.Hash {
+	var topic types.Hash
+	copy(topic[len(topic)-len(addr):], addr.ToSlice())
+	return topic
+}
+
+// transfer 从 from 向 to 转账 amount，并在交易结果中记录转账事件，使原生货币的转账可以通过 FilterLogs 查询。
+// 事件的地址为零地址，主题依次为 TransferTopic、AddressTopic(from) 和 AddressTopic(to)，
+// 数据为 8 字节大端编码的金额。金额为 0 时不做任何修改，也不记录事件。
+func (env *TxEnv) transfer(from, to types.Address, amount uint64) error {
+	if amount == 0 {
+		return nil
+	}
+	if err := env.State.transfer(from, to, amount); err != nil {
+		return err
+	}
+	env.Result.Logs = append(env.Result.Logs, &vm.Log{
+		Topics: []types.Hash{TransferTopic, AddressTopic(from), AddressTopic(to)},
+		Data:   binary.BigEndian.AppendUint64(nil, amount),
+	})
+	return nil
+}
+
+var (
+	txHandlersLock sync.RWMutex
+	txHandlers     = map[TxKind]TxHandler{
+		TxKindCall:     callHandler{},
+		TxKindDeploy:   deployHandler{},
+		TxKindTransfer: transferHandler{},
+		TxKindStake:    stakeHandler{},
+		TxKindUnstake:  unstakeHandler{},
+	}
+)
+
+// RegisterTxHandler 注册一种交易的处理器，通常在包的 init 函数中调用。
+//
+// 参数:
+//
+//	kind - 交易种类的标签。
+//	handler - 该种类交易的处理器。
+//
+// 返回值:
+//
+//	error - 该种类已经注册过处理器时返回错误。
+func RegisterTxHandler(kind TxKind, handler TxHandler) error {
+	txHandlersLock.Lock()
+	defer txHandlersLock.Unlock()
+	if h, ok := txHandlers[kind]; ok {
+		return fmt.Errorf("transaction kind %d is already registered as %s", byte(kind), h.Name())
+	}
+	txHandlers[kind] = handler
+	return nil
+}
+
+// LookupTxHandler 返回交易种类的处理器，未注册时第二个返回值为 false。
+func LookupTxHandler(kind TxKind) (TxHandler, bool) {
+	txHandlersLock.RLock()
+	defer txHandlersLock.RUnlock()
+	h, ok := txHandlers[kind]
+	return h, ok
+}
+
+// BaseIntrinsicGas 返回所有交易共同的固定消耗，即 TxGas 加上 Data 中每个字节的 TxDataGas，
+// 多签交易还需要为多签账户的每个成员支付 TxSignatureGas。
+// 多签交易的固定消耗只取决于账户而不是已收集的签名，因此可以在签名之前确定 GasLimit。
+func BaseIntrinsicGas(tx *Transaction) uint64 {
+	gas := TxGas + uint64(len(tx.Data))*TxDataGas
+	if tx.Multisig != nil {
+		gas += uint64(len(tx.Multisig.Members)) * TxSignatureGas
+	}
+	return gas
+}
+
+// callHandler 处理 TxKindCall 交易：向 To 转账 Value，To 是合约时以 Data 为输入执行合约代码。
+type callHandler struct{}
+
+func (callHandler) Name() string {
+	return "call"
+}
+
+func (callHandler) Validate(tx *Transaction) error {
+	if tx.Value > 0 && tx.To == (types.Address{}) {
+		return fmt.Errorf("transaction transfers value without recipient")
+	}
+	return nil
+}
+
+func (callHandler) IntrinsicGas(tx *Transaction) uint64 {
+	return BaseIntrinsicGas(tx)
+}
+
+func (callHandler) Apply(env *TxEnv, gas uint64) (uint64, error) {
+	if err := env.transfer(env.From, env.Tx.To, env.Tx.Value); err != nil {
+		return 0, err
+	}
+	code := env.State.GetCode(env.Tx.To)
+	if len(code) == 0 {
+		return gas, nil
+	}
+	ret, logs, gas, err := env.State.call(env.Block, env.Tx, env.From, code, gas)
+	env.Result.ReturnData = ret
+	env.Result.Logs = append(env.Result.Logs, logs...)
+	return gas, err
+}
+
+// deployHandler 处理 TxKindDeploy 交易：将 Data 部署为合约代码，并将 Value 转入新合约。
+type deployHandler struct{}
+
+func (deployHandler) Name() string {
+	return "deploy"
+}
+
+func (deployHandler) Validate(tx *Transaction) error {
+	if tx.To != (types.Address{}) {
+		return fmt.Errorf("deploy transaction has recipient %s", tx.To)
+	}
+	if len(tx.Data) == 0 {
+		return fmt.Errorf("deploy transaction has no code")
+	}
+	return nil
+}
+
+func (deployHandler) IntrinsicGas(tx *Transaction) uint64 {
+	return BaseIntrinsicGas(tx) + TxCreateGas
+}
+
+func (deployHandler) Apply(env *TxEnv, gas uint64) (uint64, error) {
+	addr := ContractAddress(env.From, env.Tx.Nonce)
+	env.Result.ContractAddress = addr
+	gas, err := env.State.deploy(addr, env.Tx.Data, gas)
+	if err != nil {
+		return gas, err
+	}
+	if err := env.transfer(env.From, addr, env.Tx.Value); err != nil {
+		return 0, err
+	}
+	return gas, nil
+}
+
+// transferHandler 处理 TxKindTransfer 交易：向 To 转账 Value，即使 To 是合约也不执行代码。
+type transferHandler struct{}
+
+func (transferHandler) Name() string {
+	return "transfer"
+}
+
+func (transferHandler) Validate(tx *Transaction) error {
+	if tx.To == (types.Address{}) {
+		return fmt.Errorf("transfer transaction has no recipient")
+	}
+	if len(tx.Data) > 0 {
+		return fmt.Errorf("transfer transaction carries %d bytes of data", len(tx.Data))
+	}
+	return nil
+}
+
+func (transferHandler) IntrinsicGas(tx *Transaction) uint64 {
+	return BaseIntrinsicGas(tx)
+}
+
+func (transferHandler) Apply(env *TxEnv, gas uint64) (uint64, error) {
+	if err := env.transfer(env.From, env.Tx.To, env.Tx.Value); err != nil {
+		return 0, err
+	}
+	return gas, nil
+}
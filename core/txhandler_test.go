@@ -0,0 +1,126 @@
+package core
+
+import (
+	"MyChain/crypto"
+	"MyChain/types"
+	"MyChain/vm"
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// txKindBurn 是测试注册的交易种类，从发送者余额中销毁 Value。
+const txKindBurn TxKind = 0xf0
+
+type burnHandler struct{}
+
+func (burnHandler) Name() string {
+	return "burn"
+}
+
+func (burnHandler) Validate(tx *Transaction) error {
+	if tx.Value == 0 {
+		return fmt.Errorf("burn transaction has no value")
+	}
+	return nil
+}
+
+func (burnHandler) IntrinsicGas(tx *Transaction) uint64 {
+	return BaseIntrinsicGas(tx)
+}
+
+func (burnHandler) Apply(env *TxEnv, gas uint64) (uint64, error) {
+	return gas, env.State.SubBalance(env.From, env.Tx.Value)
+}
+
+func init() {
+	if err := RegisterTxHandler(txKindBurn, burnHandler{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterTxHandler(t *testing.T) {
+	assert.NotNil(t, RegisterTxHandler(TxKindCall, burnHandler{}))
+	assert.NotNil(t, RegisterTxHandler(txKindBurn, burnHandler{}))
+	h, ok := LookupTxHandler(TxKindDeploy)
+	assert.True(t, ok)
+	assert.Equal(t, "deploy", h.Name())
+	_, ok = LookupTxHandler(0xee)
+	assert.False(t, ok)
+
+	assert.Equal(t, "transfer", TxKindTransfer.String())
+	assert.Equal(t, "burn", txKindBurn.String())
+	assert.Equal(t, "unknown(238)", TxKind(0xee).String())
+}
+
+func TestState_ApplyTransaction_Handler(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 100000))
+
+	// 注册的处理器负责交易的校验和状态转换，手续费和 nonce 照常处理
+	tx := &Transaction{Kind: txKindBurn, Value: 40, GasLimit: TxGas, GasPrice: 1}
+	assert.Nil(t, tx.Sign(privateKey))
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Equal(t, Account{Balance: 100000 - 40 - TxGas, Nonce: 1}, s.GetAccount(from))
+
+	tx = &Transaction{Kind: txKindBurn, Nonce: 1, GasLimit: TxGas}
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, BlockContext{}, tx))
+
+	// 未注册的种类
+	tx = &Transaction{Kind: 0xee, Nonce: 1, GasLimit: TxGas}
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, BlockContext{}, tx))
+	assert.Equal(t, uint64(1), s.Nonce(from))
+}
+
+func TestState_ApplyTransaction_Transfer(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	from := privateKey.PublicKey().Address()
+	s := NewState()
+	assert.Nil(t, s.AddBalance(from, 1000000))
+	contract := deployContract(t, s, privateKey, 0, []byte{byte(vm.ADD)})
+
+	// 转账交易不执行合约代码
+	tx := signedTransfer(t, privateKey, 1, contract, 10)
+	result, err := s.ApplyTransaction(BlockContext{}, tx)
+	assert.Nil(t, err)
+	assert.False(t, result.Failed())
+	assert.Equal(t, uint64(10), s.Balance(contract))
+
+	// 转账交易必须有接收者且不能携带数据
+	tx = NewTransferTransaction(randomAddress(), 10)
+	tx.Nonce = 2
+	tx.Data = []byte{1}
+	tx.GasLimit = IntrinsicGas(tx)
+	assert.Nil(t, tx.Sign(privateKey))
+	assert.NotNil(t, applyTx(s, BlockContext{}, tx))
+	assert.NotNil(t, applyTx(s, BlockContext{}, signedTransfer(t, privateKey, 2, types.Address{}, 0)))
+}
+
+func TestGobTxDecoder_Dispatch(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	encode := func(tx *Transaction) *bytes.Buffer {
+		assert.Nil(t, tx.Sign(privateKey))
+		buf := &bytes.Buffer{}
+		assert.Nil(t, tx.Encode(NewGobTxEncoder(buf)))
+		return buf
+	}
+
+	dec := new(Transaction)
+	assert.Nil(t, dec.Decode(NewGobTxDecoder(encode(&Transaction{Kind: txKindBurn, Value: 1}))))
+	assert.Equal(t, txKindBurn, dec.Kind)
+
+	// 解码时按标签分派给处理器校验
+	dec = new(Transaction)
+	assert.NotNil(t, dec.Decode(NewGobTxDecoder(encode(&Transaction{Kind: txKindBurn}))))
+	dec = new(Transaction)
+	assert.NotNil(t, dec.Decode(NewGobTxDecoder(encode(&Transaction{Kind: 0xee}))))
+	dec = new(Transaction)
+	assert.NotNil(t, dec.Decode(NewGobTxDecoder(encode(&Transaction{Kind: TxKindDeploy, To: randomAddress(), Data: []byte{1}}))))
+}
@@ -4,6 +4,8 @@ import "fmt"
 
 type Validator interface {
 	ValidateBlock(block *Block) error
+	// ValidateState 在区块应用到状态之后调用，result 是应用该区块的结果
+	ValidateState(block *Block, result *BlockResult) error
 }
 
 type BlockValidator struct {
@@ -41,6 +43,14 @@ func (v *BlockValidator) ValidateBlock(block *Block) error {
 	if hash != block.PrevBlockHash {
 		return fmt.Errorf("invalid prev block hash, expected %s, got %s", hash, block.PrevBlockHash)
 	}
+	// 校验Block的基础费用是否符合根据父区块计算的结果
+	if baseFee := v.bc.NextBaseFee(); block.BaseFee != baseFee {
+		return fmt.Errorf("invalid base fee, expected %d, got %d", baseFee, block.BaseFee)
+	}
+	// 校验Block中所有交易的GasLimit之和不超过区块gas上限
+	if err := v.validateGasLimit(block); err != nil {
+		return err
+	}
 	// 验证Block本身的有效性
 	if err := block.Verify(); err != nil {
 		return err
@@ -48,3 +58,43 @@ func (v *BlockValidator) ValidateBlock(block *Block) error {
 	// 如果一切正常，返回nil
 	return nil
 }
+
+// validateGasLimit 检查区块中所有交易的 GasLimit 之和是否超过区块 gas 上限。
+func (v *BlockValidator) validateGasLimit(block *Block) error {
+	limit := v.bc.BlockGasLimit()
+	var total uint64
+	for i := range block.Transactions {
+		gas := block.Transactions[i].GasLimit
+		if gas > limit || total > limit-gas {
+			return fmt.Errorf("block gas limit %d exceeded at transaction index %d", limit, i)
+		}
+		total += gas
+	}
+	return nil
+}
+
+// ValidateState 验证区块头中的状态根、收据根、事件布隆过滤器和消耗的 gas 是否与应用该区块的结果一致。
+//
+// 参数:
+//
+//	block: 已应用的区块。
+//	result: 应用该区块的结果。
+//
+// 返回:
+//
+//	error: 状态根、收据根、事件布隆过滤器或消耗的 gas 不一致时返回错误；否则返回nil。
+func (v *BlockValidator) ValidateState(block *Block, result *BlockResult) error {
+	if block.GasUsed != result.GasUsed {
+		return fmt.Errorf("invalid gas used, expected %d, got %d", result.GasUsed, block.GasUsed)
+	}
+	if root := result.State.Root(); block.StateRoot != root {
+		return fmt.Errorf("invalid state root, expected %s, got %s", root, block.StateRoot)
+	}
+	if root := ReceiptsRoot(result.Receipts); block.ReceiptsRoot != root {
+		return fmt.Errorf("invalid receipts root, expected %s, got %s", root, block.ReceiptsRoot)
+	}
+	if bloom := CreateBloom(result.Receipts); block.LogsBloom != bloom {
+		return fmt.Errorf("invalid logs bloom, expected %s, got %s", bloom, block.LogsBloom)
+	}
+	return nil
+}
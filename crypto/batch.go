@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// BatchItem 是批量验证中的一项。PublicKey 为空时从签名恢复签名者公钥。
+type BatchItem struct {
+	PublicKey *PublicKey
+	Data      []byte
+	Signature *Signature
+}
+
+// RunBatch 使用 workers 个协程并发执行 verify(0) 到 verify(n-1)，返回出错的最小下标。
+// workers 小于等于 0 时使用 CPU 核数。发现错误后不再执行更大下标的任务。
+//
+// 参数:
+//
+//	n - 任务数量。
+//	workers - 并发协程数量。
+//	verify - 验证第 i 项的函数，需可被并发调用。
+//
+// 返回值:
+//
+//	int - 第一个失败项的下标，全部成功时为 -1。
+//	error - 第一个失败项返回的错误。
+func RunBatch(n, workers int, verify func(i int) error) (int, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+	// 任务较少时直接顺序执行，避免协程调度的开销
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			if err := verify(i); err != nil {
+				return i, err
+			}
+		}
+		return -1, nil
+	}
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Int64
+		lock     sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	failed.Store(int64(n))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				// 下标按递增顺序分发，超过已知失败下标的任务无需再执行
+				i := next.Add(1) - 1
+				if i >= failed.Load() {
+					return
+				}
+				if err := verify(int(i)); err != nil {
+					lock.Lock()
+					if i < failed.Load() {
+						failed.Store(i)
+						firstErr = err
+					}
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if idx := int(failed.Load()); idx < n {
+		return idx, firstErr
+	}
+	return -1, nil
+}
+
+// VerifyBatch 并发验证一批签名，返回第一个无效签名的下标。
+//
+// 参数:
+//
+//	items - 待验证的签名。
+//	workers - 并发协程数量，小于等于 0 时使用 CPU 核数。
+//
+// 返回值:
+//
+//	int - 第一个无效签名的下标，全部有效时为 -1。
+//	error - 第一个无效签名的错误原因。
+func VerifyBatch(items []BatchItem, workers int) (int, error) {
+	return RunBatch(len(items), workers, func(i int) error {
+		item := items[i]
+		if item.Signature == nil {
+			return fmt.Errorf("signature is nil")
+		}
+		if item.PublicKey == nil {
+			_, err := RecoverPublicKey(item.Data, item.Signature)
+			return err
+		}
+		if !item.Signature.Verify(*item.PublicKey, item.Data) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func randomBatch(t testing.TB, n int) []BatchItem {
+	items := make([]BatchItem, n)
+	for i := range items {
+		privateKey := GeneratePrivateKey()
+		publicKey := privateKey.PublicKey()
+		data := []byte(fmt.Sprintf("msg-%d", i))
+		sign, err := privateKey.Sign(data)
+		assert.Nil(t, err)
+		items[i] = BatchItem{PublicKey: &publicKey, Data: data, Signature: sign}
+	}
+	return items
+}
+
+func TestVerifyBatch(t *testing.T) {
+	items := randomBatch(t, 64)
+	for _, workers := range []int{0, 1, 4} {
+		idx, err := VerifyBatch(items, workers)
+		assert.Nil(t, err)
+		assert.Equal(t, -1, idx)
+	}
+
+	// 不提供公钥时从签名恢复
+	items[3].PublicKey = nil
+	idx, err := VerifyBatch(items, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, idx)
+
+	items[40].Data = []byte("tampered")
+	items[17].Signature = nil
+	for _, workers := range []int{1, 4, 16} {
+		idx, err := VerifyBatch(items, workers)
+		assert.NotNil(t, err)
+		assert.Equal(t, 17, idx)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	idx, err := RunBatch(0, 4, func(i int) error { return fmt.Errorf("never") })
+	assert.Nil(t, err)
+	assert.Equal(t, -1, idx)
+
+	for i := 0; i < 20; i++ {
+		idx, err := RunBatch(1000, 8, func(i int) error {
+			if i%100 == 99 {
+				return fmt.Errorf("fail %d", i)
+			}
+			return nil
+		})
+		assert.Equal(t, 99, idx)
+		assert.EqualError(t, err, "fail 99")
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	items := randomBatch(b, 256)
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VerifyBatch(items, workers)
+			}
+		})
+	}
+}
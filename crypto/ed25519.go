@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+)
+
+// ed25519Scheme 实现 Ed25519 签名。
+// Ed25519 无法从签名恢复公钥，因此签名中携带签名者公钥。
+type ed25519Scheme struct{}
+
+func (ed25519Scheme) Algorithm() Algorithm {
+	return AlgoEd25519
+}
+
+func (ed25519Scheme) GenerateKey() (PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return PrivateKey{algo: AlgoEd25519, edKey: key}, nil
+}
+
+// PrivateKeyFromBytes 从 32 字节种子解析私钥。
+func (ed25519Scheme) PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	if len(b) != ed25519.SeedSize {
+		return PrivateKey{}, fmt.Errorf("given bytes with length %d should be %d", len(b), ed25519.SeedSize)
+	}
+	return PrivateKey{algo: AlgoEd25519, edKey: ed25519.NewKeyFromSeed(b)}, nil
+}
+
+func (ed25519Scheme) PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	if len(b) != ed25519.PublicKeySize {
+		return PublicKey{}, fmt.Errorf("given bytes with length %d should be %d", len(b), ed25519.PublicKeySize)
+	}
+	key := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	copy(key, b)
+	return PublicKey{Algo: AlgoEd25519, Ed25519: key}, nil
+}
+
+func (ed25519Scheme) Sign(k PrivateKey, data []byte) (*Signature, error) {
+	return &Signature{
+		Algo:   AlgoEd25519,
+		Raw:    ed25519.Sign(k.edKey, data),
+		Signer: k.edKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+func (ed25519Scheme) Verify(pubKey PublicKey, data []byte, sig *Signature) bool {
+	if len(pubKey.Ed25519) != ed25519.PublicKeySize || len(sig.Raw) != ed25519.SignatureSize {
+		return false
+	}
+	// 标准库的实现会拒绝 S >= L 的非规范签名
+	return ed25519.Verify(pubKey.Ed25519, data, sig.Raw)
+}
+
+func (s ed25519Scheme) Recover(data []byte, sig *Signature) (PublicKey, error) {
+	pubKey, err := s.PublicKeyFromBytes(sig.Signer)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid signer: %w", err)
+	}
+	if !s.Verify(pubKey, data, sig) {
+		return PublicKey{}, fmt.Errorf("invalid signature")
+	}
+	return pubKey, nil
+}
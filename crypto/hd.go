@@ -0,0 +1,240 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// HardenedOffset 是强化派生索引的起始值，索引大于等于该值时使用强化派生。
+const HardenedOffset uint32 = 0x80000000
+
+var masterKeySalt = map[Algorithm][]byte{
+	AlgoP256:    []byte("Nist256p1 seed"),
+	AlgoEd25519: []byte("ed25519 seed"),
+}
+
+// ExtendedKey 是按照 SLIP-0010 规范派生的分层确定性密钥，由密钥和链码组成。
+// 仅包含公钥的扩展密钥只能进行非强化派生。
+type ExtendedKey struct {
+	algo      Algorithm
+	private   []byte
+	public    []byte
+	chainCode []byte
+	depth     uint8
+}
+
+// NewMasterKey 根据种子生成主扩展密钥。
+//
+// 参数:
+//
+//	algo - 派生使用的签名算法，支持 P-256 和 Ed25519。
+//	seed - 种子，长度为 16 到 64 字节。
+//
+// 返回值:
+//
+//	*ExtendedKey - 主扩展密钥。
+//	error - 算法不支持或种子长度不合法时返回错误。
+func NewMasterKey(algo Algorithm, seed []byte) (*ExtendedKey, error) {
+	salt, ok := masterKeySalt[algo]
+	if !ok {
+		return nil, fmt.Errorf("hd derivation does not support algorithm %s", algo)
+	}
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed length %d should be between 16 and 64", len(seed))
+	}
+	i := hmacSHA512(salt, seed)
+	// P-256 的密钥必须在 [1, N-1] 范围内，否则以 I 作为数据重新计算
+	for algo == AlgoP256 && !validScalar(i[:32]) {
+		i = hmacSHA512(salt, i)
+	}
+	return newExtendedPrivateKey(algo, i[:32], i[32:], 0)
+}
+
+func newExtendedPrivateKey(algo Algorithm, private, chainCode []byte, depth uint8) (*ExtendedKey, error) {
+	key, err := NewPrivateKey(algo, private)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtendedKey{
+		algo:      algo,
+		private:   private,
+		public:    key.PublicKey().ToSlice(),
+		chainCode: chainCode,
+		depth:     depth,
+	}, nil
+}
+
+// Child 派生指定索引的子扩展密钥，索引大于等于 HardenedOffset 时为强化派生。
+// Ed25519 只支持强化派生，仅包含公钥的扩展密钥不支持强化派生。
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	hardened := index >= HardenedOffset
+	if k.algo == AlgoEd25519 && !hardened {
+		return nil, fmt.Errorf("ed25519 only supports hardened derivation")
+	}
+	if k.private == nil && hardened {
+		return nil, fmt.Errorf("cannot derive hardened child from public key")
+	}
+	if k.depth == 255 {
+		return nil, fmt.Errorf("maximum derivation depth reached")
+	}
+
+	data := make([]byte, 0, 37)
+	if hardened {
+		data = append(append(data, 0), k.private...)
+	} else {
+		data = append(data, k.public...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	i := hmacSHA512(k.chainCode, data)
+
+	if k.algo == AlgoEd25519 {
+		return newExtendedPrivateKey(k.algo, i[:32], i[32:], k.depth+1)
+	}
+	for {
+		child, ok := k.p256Child(i[:32], i[32:])
+		if ok {
+			return child, nil
+		}
+		// 结果无效时以 0x01 || IR || index 重新计算
+		data = append([]byte{1}, i[32:]...)
+		data = binary.BigEndian.AppendUint32(data, index)
+		i = hmacSHA512(k.chainCode, data)
+	}
+}
+
+// p256Child 由 IL 和 IR 计算 P-256 子密钥，结果无效时返回 false。
+func (k *ExtendedKey) p256Child(il, ir []byte) (*ExtendedKey, bool) {
+	if !validScalar(il) {
+		return nil, false
+	}
+	if k.private != nil {
+		// 子私钥 = IL + k mod N
+		d := new(big.Int).SetBytes(il)
+		d.Add(d, new(big.Int).SetBytes(k.private)).Mod(d, curveOrder)
+		if d.Sign() == 0 {
+			return nil, false
+		}
+		child, err := newExtendedPrivateKey(k.algo, d.FillBytes(make([]byte, 32)), ir, k.depth+1)
+		return child, err == nil
+	}
+	// 子公钥 = IL*G + K
+	curve := elliptic.P256()
+	px, py := elliptic.UnmarshalCompressed(curve, k.public)
+	x1, y1 := curve.ScalarBaseMult(il)
+	x, y := curve.Add(x1, y1, px, py)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, false
+	}
+	return &ExtendedKey{
+		algo:      k.algo,
+		public:    elliptic.MarshalCompressed(curve, x, y),
+		chainCode: ir,
+		depth:     k.depth + 1,
+	}, true
+}
+
+// Derive 按照路径依次派生子扩展密钥，路径格式如 m/44'/0'/0'/0/1，' 或 H 表示强化派生。
+func (k *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, index := range indexes {
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Neuter 返回仅包含公钥的扩展密钥，可用于在不接触私钥的情况下派生非强化子公钥。
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	return &ExtendedKey{
+		algo:      k.algo,
+		public:    k.public,
+		chainCode: k.chainCode,
+		depth:     k.depth,
+	}
+}
+
+// IsPrivate 判断扩展密钥是否包含私钥。
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.private != nil
+}
+
+// PrivateKey 返回扩展密钥对应的私钥。
+func (k *ExtendedKey) PrivateKey() (PrivateKey, error) {
+	if k.private == nil {
+		return PrivateKey{}, fmt.Errorf("extended key has no private key")
+	}
+	return NewPrivateKey(k.algo, k.private)
+}
+
+// PublicKey 返回扩展密钥对应的公钥。
+func (k *ExtendedKey) PublicKey() PublicKey {
+	pub, err := NewPublicKey(k.algo, k.public)
+	if err != nil {
+		panic(err)
+	}
+	return pub
+}
+
+// ChainCode 返回扩展密钥的链码。
+func (k *ExtendedKey) ChainCode() []byte {
+	return append([]byte{}, k.chainCode...)
+}
+
+// Depth 返回扩展密钥相对主密钥的派生深度。
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// ParsePath 解析派生路径，返回各级索引。
+//
+// 参数:
+//
+//	path - 以 m 开头、以 / 分隔的派生路径，如 m/0'/1/2H。
+//
+// 返回值:
+//
+//	[]uint32 - 各级索引，强化派生的索引已加上 HardenedOffset。
+//	error - 路径格式错误时返回错误。
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: should start with m", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		offset := uint32(0)
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "H") {
+			offset = HardenedOffset
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("invalid derivation path %q: bad index %q", path, part)
+		}
+		indexes = append(indexes, uint32(index)+offset)
+	}
+	return indexes, nil
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// validScalar 判断 b 是否为 [1, N-1] 范围内的 P-256 标量。
+func validScalar(b []byte) bool {
+	d := new(big.Int).SetBytes(b)
+	return d.Sign() > 0 && d.Cmp(curveOrder) < 0
+}
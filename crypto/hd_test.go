@@ -0,0 +1,154 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type hdTestVector struct {
+	path      string
+	chainCode string
+	private   string
+	public    string
+}
+
+// SLIP-0010 测试向量 1，种子为 000102030405060708090a0b0c0d0e0f。
+var hdTestSeed = "000102030405060708090a0b0c0d0e0f"
+
+var hdP256Vectors = []hdTestVector{
+	{
+		path:      "m",
+		chainCode: "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea",
+		private:   "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+		public:    "0266874dc6ade47b3ecd096745ca09bcd29638dd52c2c12117b11ed3e458cfa9e8",
+	},
+	{
+		path:      "m/0H",
+		chainCode: "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11",
+		private:   "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+		public:    "0384610f5ecffe8fda089363a41f56a5c7ffc1d81b59a612d0d649b2d22355590c",
+	},
+	{
+		path:      "m/0H/1",
+		chainCode: "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c",
+		private:   "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129",
+		public:    "03526c63f8d0b4bbbf9c80df553fe66742df4676b241dabefdef67733e070f6844",
+	},
+	{
+		path:      "m/0H/1/2H",
+		chainCode: "98c7514f562e64e74170cc3cf304ee1ce54d6b6da4f880f313e8204c2a185318",
+		private:   "694596e8a54f252c960eb771a3c41e7e32496d03b954aeb90f61635b8e092aa7",
+		public:    "0359cf160040778a4b14c5f4d7b76e327ccc8c4a6086dd9451b7482b5a4972dda0",
+	},
+	{
+		path:      "m/0H/1/2H/2",
+		chainCode: "ba96f776a5c3907d7fd48bde5620ee374d4acfd540378476019eab70790c63a0",
+		private:   "5996c37fd3dd2679039b23ed6f70b506c6b56b3cb5e424681fb0fa64caf82aaa",
+		public:    "029f871f4cb9e1c97f9f4de9ccd0d4a2f2a171110c61178f84430062230833ff20",
+	},
+	{
+		path:      "m/0H/1/2H/2/1000000000",
+		chainCode: "b9b7b82d326bb9cb5b5b121066feea4eb93d5241103c9e7a18aad40f1dde8059",
+		private:   "21c4f269ef0a5fd1badf47eeacebeeaa3de22eb8e5b0adcd0f27dd99d34d0119",
+		public:    "02216cd26d31147f72427a453c443ed2cde8a1e53c9cc44e5ddf739725413fe3f4",
+	},
+}
+
+// Ed25519 公钥省略了 SLIP-0010 中的 00 前缀。
+var hdEd25519Vectors = []hdTestVector{
+	{
+		path:      "m",
+		chainCode: "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb",
+		private:   "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		public:    "a4b2856bfec510abab89753fac1ac0e1112364e7d250545963f135f2a33188ed",
+	},
+	{
+		path:      "m/0H",
+		chainCode: "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69",
+		private:   "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		public:    "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c",
+	},
+	{
+		path:      "m/0H/1H",
+		chainCode: "a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14",
+		private:   "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+		public:    "1932a5270f335bed617d5b935c80aedb1a35bd9fc1e31acafd5372c30f5c1187",
+	},
+}
+
+func testHDVectors(t *testing.T, algo Algorithm, vectors []hdTestVector) {
+	seed, err := hex.DecodeString(hdTestSeed)
+	assert.Nil(t, err)
+	master, err := NewMasterKey(algo, seed)
+	assert.Nil(t, err)
+
+	for _, v := range vectors {
+		key, err := master.Derive(v.path)
+		assert.Nil(t, err, v.path)
+		assert.Equal(t, v.chainCode, hex.EncodeToString(key.ChainCode()), v.path)
+
+		privateKey, err := key.PrivateKey()
+		assert.Nil(t, err, v.path)
+		assert.Equal(t, v.private, privateKey.Hex(), v.path)
+		assert.Equal(t, v.public, privateKey.PublicKey().Hex(), v.path)
+	}
+}
+
+func TestHD_P256_Vectors(t *testing.T) {
+	testHDVectors(t, AlgoP256, hdP256Vectors)
+}
+
+func TestHD_Ed25519_Vectors(t *testing.T) {
+	testHDVectors(t, AlgoEd25519, hdEd25519Vectors)
+}
+
+func TestHD_PublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString(hdTestSeed)
+	master, err := NewMasterKey(AlgoP256, seed)
+	assert.Nil(t, err)
+	account, err := master.Derive("m/44'/0'")
+	assert.Nil(t, err)
+
+	public := account.Neuter()
+	assert.False(t, public.IsPrivate())
+	_, err = public.PrivateKey()
+	assert.NotNil(t, err)
+	_, err = public.Child(HardenedOffset)
+	assert.NotNil(t, err)
+
+	for i := uint32(0); i < 5; i++ {
+		privChild, err := account.Child(i)
+		assert.Nil(t, err)
+		pubChild, err := public.Child(i)
+		assert.Nil(t, err)
+		assert.Equal(t, privChild.PublicKey().Address(), pubChild.PublicKey().Address())
+		assert.Equal(t, privChild.ChainCode(), pubChild.ChainCode())
+	}
+}
+
+func TestHD_Ed25519_NonHardened(t *testing.T) {
+	seed, _ := hex.DecodeString(hdTestSeed)
+	master, err := NewMasterKey(AlgoEd25519, seed)
+	assert.Nil(t, err)
+	_, err = master.Child(0)
+	assert.NotNil(t, err)
+}
+
+func TestParsePath(t *testing.T) {
+	indexes, err := ParsePath("m/44'/0H/1")
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{HardenedOffset + 44, HardenedOffset, 1}, indexes)
+
+	indexes, err = ParsePath("m")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(indexes))
+
+	for _, path := range []string{"", "44'/0'", "m/", "m/x", "m/2147483648", "m/-1"} {
+		_, err := ParsePath(path)
+		assert.NotNil(t, err, path)
+	}
+
+	_, err = NewMasterKey(AlgoP256, []byte("short"))
+	assert.NotNil(t, err)
+}
@@ -3,17 +3,25 @@ package crypto
 import (
 	"MyChain/types"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
-	"crypto/rand"
 	"crypto/sha256"
+	"fmt"
 	"math/big"
 )
 
+// SignatureLength 是 P-256 签名紧凑序列化后的固定长度，即 R 和 S 各 32 字节。
+const SignatureLength = 64
+
 type PrivateKey struct {
-	key *ecdsa.PrivateKey
+	algo Algorithm
+	// key 是 P-256 私钥，edKey 是 Ed25519 私钥，二者根据 algo 只使用其一
+	key   *ecdsa.PrivateKey
+	edKey ed25519.PrivateKey
 }
 
-// Sign 使用私钥对给定数据进行数字签名。
+// Sign 使用私钥对给定数据进行数字签名，签名算法由私钥的算法决定。
+// 生成的签名总能恢复出签名者公钥。
 //
 // 参数:
 // data []byte - 需要签名的数据。
@@ -22,28 +30,51 @@ type PrivateKey struct {
 // *Signature - 生成的签名对象。
 // error - 如果签名过程中发生错误，则返回错误对象。
 func (k PrivateKey) Sign(data []byte) (*Signature, error) {
-	// 使用ECDSA算法对数据进行签名
-	r, s, err := ecdsa.Sign(rand.Reader, k.key, data)
+	s, err := LookupScheme(k.algo)
 	if err != nil {
-		return nil, err // 如果签名过程中出现错误，返回错误
+		return nil, err
 	}
-	return &Signature{r, s}, nil // 成功签名后，返回签名对象
+	return s.Sign(k, data)
 }
 
 func GeneratePrivateKey() PrivateKey {
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key, err := GenerateKey(AlgoP256)
 	if err != nil {
 		panic(err)
 	}
-	return PrivateKey{key}
+	return key
+}
+
+// Algorithm 返回私钥使用的签名算法。
+func (k PrivateKey) Algorithm() Algorithm {
+	return k.algo
 }
 
 func (k PrivateKey) PublicKey() PublicKey {
+	if k.algo == AlgoEd25519 {
+		return PublicKey{Algo: AlgoEd25519, Ed25519: k.edKey.Public().(ed25519.PublicKey)}
+	}
 	return PublicKey{Key: &k.key.PublicKey}
 }
 
 type PublicKey struct {
-	Key *ecdsa.PublicKey
+	Algo Algorithm
+	// Key 是 P-256 公钥，Ed25519 是 Ed25519 公钥，二者根据 Algo 只使用其一
+	Key     *ecdsa.PublicKey
+	Ed25519 ed25519.PublicKey
+}
+
+// IsValid 判断公钥是否包含其算法要求的密钥，零值和缺少密钥的公钥无效。
+// 无效的公钥不能调用 ToSlice 和 Address。
+func (k PublicKey) IsValid() bool {
+	switch k.Algo {
+	case AlgoP256:
+		return k.Key != nil && k.Key.X != nil && k.Key.Y != nil
+	case AlgoEd25519:
+		return len(k.Ed25519) == ed25519.PublicKeySize
+	default:
+		return false
+	}
 }
 
 // ToSlice 将公钥转换为字节切片。
@@ -54,8 +85,11 @@ type PublicKey struct {
 //
 // 返回值:
 //
-//	[]byte - 经过压缩的公钥字节切片。
+//	[]byte - P-256 公钥为 SEC1 压缩格式，Ed25519 公钥为 32 字节原始格式。
 func (k PublicKey) ToSlice() []byte {
+	if k.Algo == AlgoEd25519 {
+		return append([]byte{}, k.Ed25519...)
+	}
 	// 使用椭圆曲线算法将公钥压缩并转换为字节切片
 	return elliptic.MarshalCompressed(k.Key, k.Key.X, k.Key.Y)
 }
@@ -69,14 +103,80 @@ func (k PublicKey) Address() types.Address {
 	// 使用SHA256算法计算公钥的哈希值
 	bytes := sha256.Sum256(k.ToSlice())
 	// 从哈希值的后20字节创建并返回地址
-	return types.AddressFromBytes(bytes[len(bytes)-20:])
+	return types.MustAddressFromBytes(bytes[len(bytes)-20:])
 }
 
 type Signature struct {
+	Algo Algorithm
+	// R, S 是 P-256 签名的分量，V 是恢复 ID，用于从签名中恢复公钥
 	R, S *big.Int
+	V    byte
+	// Raw 是 Ed25519 签名，Signer 是签名者公钥（Ed25519 无法从签名恢复公钥）
+	Raw    []byte
+	Signer []byte
+}
+
+// SignatureFromBytes 从 64 字节的紧凑格式（R || S，大端序）解析 P-256 签名。
+//
+// 参数:
+//
+//	b - 紧凑格式的签名字节。
+//
+// 返回值:
+//
+//	*Signature - 解析得到的签名。
+//	error - 长度不正确或签名不是规范的低 S 形式时返回错误。
+func SignatureFromBytes(b []byte) (*Signature, error) {
+	if len(b) != SignatureLength {
+		return nil, fmt.Errorf("given bytes with length %d should be %d", len(b), SignatureLength)
+	}
+	sig := &Signature{
+		R: new(big.Int).SetBytes(b[:32]),
+		S: new(big.Int).SetBytes(b[32:]),
+	}
+	if !sig.IsCanonical() {
+		return nil, fmt.Errorf("signature is not canonical")
+	}
+	return sig, nil
+}
+
+// Bytes 将签名序列化为 64 字节的紧凑格式。
+// P-256 签名为 R 和 S 各占 32 字节，大端序，左侧补零；Ed25519 签名为其原始格式。
+// R 或 S 为空、为负数或超过 32 字节时，该分量编码为全零。有效签名的分量不为零，
+// 因此格式错误的签名不会与任何有效签名的编码相同，也不会使调用方 panic。
+func (s Signature) Bytes() []byte {
+	if s.Algo == AlgoEd25519 {
+		return append([]byte{}, s.Raw...)
+	}
+	b := make([]byte, SignatureLength)
+	fillScalar(b[:32], s.R)
+	fillScalar(b[32:], s.S)
+	return b
+}
+
+// fillScalar 将 x 以大端序写入 buf，x 为空、为负数或超过 buf 的长度时保持 buf 为全零。
+func fillScalar(buf []byte, x *big.Int) {
+	if x == nil || x.Sign() < 0 || x.BitLen() > len(buf)*8 {
+		return
+	}
+	x.FillBytes(buf)
+}
+
+// IsCanonical 判断签名是否为规范形式。
+// P-256 签名要求 0 < R < N 且 0 < S <= N/2；Ed25519 签名的规范性在验证时检查。
+func (s Signature) IsCanonical() bool {
+	switch s.Algo {
+	case AlgoP256:
+		return p256Canonical(&s)
+	case AlgoEd25519:
+		return len(s.Raw) == ed25519.SignatureSize && len(s.Signer) == ed25519.PublicKeySize
+	default:
+		return false
+	}
 }
 
 // Verify 使用给定的公钥验证签名是否有效。
+// 签名与公钥的算法必须一致，非规范签名一律视为无效。
 //
 // 参数:
 //
@@ -87,6 +187,12 @@ type Signature struct {
 //
 //	返回一个布尔值，表示签名是否有效。
 func (s Signature) Verify(pubKey PublicKey, data []byte) bool {
-	// 使用ECDSA算法验证签名
-	return ecdsa.Verify(pubKey.Key, data, s.R, s.S)
+	if s.Algo != pubKey.Algo {
+		return false
+	}
+	scheme, err := LookupScheme(s.Algo)
+	if err != nil {
+		return false
+	}
+	return scheme.Verify(pubKey, data, &s)
 }
@@ -3,6 +3,7 @@ package crypto
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 )
 
@@ -30,3 +31,74 @@ func TestKeypair_Sign_Verify_Fail(t *testing.T) {
 	assert.False(t, sign.Verify(otherPubKey, msg))
 	assert.False(t, sign.Verify(publicKey, []byte("no")))
 }
+
+func TestKeypair_Sign_LowS(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	publicKey := privateKey.PublicKey()
+	msg := []byte("hello")
+
+	for i := 0; i < 100; i++ {
+		sign, err := privateKey.Sign(msg)
+		assert.Nil(t, err)
+		assert.True(t, sign.IsCanonical())
+		assert.True(t, sign.S.Cmp(curveHalfOrder) <= 0)
+		assert.True(t, sign.Verify(publicKey, msg))
+	}
+}
+
+func TestSignature_Verify_RejectHighS(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	publicKey := privateKey.PublicKey()
+	msg := []byte("hello")
+
+	sign, err := privateKey.Sign(msg)
+	assert.Nil(t, err)
+
+	// 翻转 S 得到数学上有效但不规范的签名
+	malleated := &Signature{R: sign.R, S: new(big.Int).Sub(curveOrder, sign.S)}
+	assert.False(t, malleated.IsCanonical())
+	assert.False(t, malleated.Verify(publicKey, msg))
+}
+
+func TestSignature_Bytes(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	publicKey := privateKey.PublicKey()
+	msg := []byte("hello")
+
+	sign, err := privateKey.Sign(msg)
+	assert.Nil(t, err)
+
+	b := sign.Bytes()
+	assert.Equal(t, SignatureLength, len(b))
+
+	dec, err := SignatureFromBytes(b)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, sign.R.Cmp(dec.R))
+	assert.Equal(t, 0, sign.S.Cmp(dec.S))
+	assert.True(t, dec.Verify(publicKey, msg))
+
+	_, err = SignatureFromBytes(b[:63])
+	assert.NotNil(t, err)
+
+	high := &Signature{R: sign.R, S: new(big.Int).Sub(curveOrder, sign.S)}
+	_, err = SignatureFromBytes(high.Bytes())
+	assert.NotNil(t, err)
+
+	// 格式错误的分量编码为全零，不会 panic
+	oversized := new(big.Int).Lsh(big.NewInt(1), 256)
+	malformed := []Signature{
+		{},
+		{S: sign.S},
+		{R: oversized, S: sign.S},
+		{R: big.NewInt(-1), S: sign.S},
+	}
+	for _, sig := range malformed {
+		assert.NotPanics(t, func() {
+			b := sig.Bytes()
+			assert.Equal(t, SignatureLength, len(b))
+			assert.Equal(t, make([]byte, 32), b[:32])
+			_, err := SignatureFromBytes(b)
+			assert.NotNil(t, err)
+		})
+	}
+}
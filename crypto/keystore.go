@@ -0,0 +1,367 @@
+package crypto
+
+import (
+	"MyChain/types"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// StandardScryptN 和 StandardScryptP 是生产环境使用的 scrypt 参数，约占用 256MB 内存。
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	// LightScryptN 和 LightScryptP 是资源受限环境（如测试）使用的 scrypt 参数。
+	LightScryptN = 1 << 12
+	LightScryptP = 6
+
+	// maxScryptN、maxScryptR 和 maxScryptP 是解密时接受的 scrypt 参数上限，
+	// 防止恶意的密钥文件使 scrypt 占用过多内存（128*N*R 字节）或 CPU
+	maxScryptN = 1 << 20
+	maxScryptR = 8
+	maxScryptP = 16
+
+	keystoreVersion = 1
+	keystoreExt     = ".json"
+	scryptR         = 8
+	scryptDKLen     = 32
+	saltLength      = 32
+)
+
+type encryptedKeyJSON struct {
+	Address   string     `json:"address"`
+	Algorithm string     `json:"algorithm,omitempty"`
+	Crypto    cryptoJSON `json:"crypto"`
+	Version   int        `json:"version"`
+}
+
+type cryptoJSON struct {
+	Cipher     string           `json:"cipher"`
+	CipherText string           `json:"ciphertext"`
+	Nonce      string           `json:"nonce"`
+	KDF        string           `json:"kdf"`
+	KDFParams  scryptParamsJSON `json:"kdfparams"`
+}
+
+type scryptParamsJSON struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// EncryptKey 使用口令加密私钥，返回 JSON 格式的密钥文件内容。
+// 加密密钥由 scrypt 从口令和随机盐派生，私钥使用 AES-256-GCM 加密，地址作为附加认证数据。
+//
+// 参数:
+//
+//	key - 需要加密的私钥。
+//	password - 口令。
+//	scryptN, scryptP - scrypt 的 CPU/内存开销参数和并行度参数。
+//
+// 返回值:
+//
+//	[]byte - JSON 编码的密钥文件内容。
+//	error - scrypt 参数超出 DecryptKey 接受的范围、派生密钥或加密失败时返回错误。
+func EncryptKey(key PrivateKey, password string, scryptN, scryptP int) ([]byte, error) {
+	if err := validateScryptParams(scryptN, scryptR, scryptP, scryptDKLen); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	derivedKey, err := scrypt.Key([]byte(password), salt, scryptN, scryptR, scryptP, scryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	address := key.PublicKey().Address()
+	cipherText := gcm.Seal(nil, nonce, key.Bytes(), address.ToSlice())
+
+	return json.Marshal(encryptedKeyJSON{
+		Address:   address.String(),
+		Algorithm: key.Algorithm().String(),
+		Crypto: cryptoJSON{
+			Cipher:     "aes-256-gcm",
+			CipherText: hex.EncodeToString(cipherText),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        "scrypt",
+			KDFParams: scryptParamsJSON{
+				N:     scryptN,
+				R:     scryptR,
+				P:     scryptP,
+				DKLen: scryptDKLen,
+				Salt:  hex.EncodeToString(salt),
+			},
+		},
+		Version: keystoreVersion,
+	})
+}
+
+// DecryptKey 使用口令解密 EncryptKey 生成的密钥文件内容。
+//
+// 参数:
+//
+//	data - JSON 编码的密钥文件内容。
+//	password - 口令。
+//
+// 返回值:
+//
+//	PrivateKey - 解密得到的私钥。
+//	error - 文件格式错误、scrypt 参数超出上限、口令错误或地址与私钥不匹配时返回错误。
+func DecryptKey(data []byte, password string) (PrivateKey, error) {
+	k := encryptedKeyJSON{}
+	if err := json.Unmarshal(data, &k); err != nil {
+		return PrivateKey{}, err
+	}
+	if k.Version != keystoreVersion {
+		return PrivateKey{}, fmt.Errorf("unsupported keystore version %d", k.Version)
+	}
+	if k.Crypto.Cipher != "aes-256-gcm" {
+		return PrivateKey{}, fmt.Errorf("unsupported cipher %q", k.Crypto.Cipher)
+	}
+	if k.Crypto.KDF != "scrypt" {
+		return PrivateKey{}, fmt.Errorf("unsupported kdf %q", k.Crypto.KDF)
+	}
+	// 未记录算法的密钥文件默认为 P-256
+	algo := AlgoP256
+	if k.Algorithm != "" {
+		parsed, err := AlgorithmFromString(k.Algorithm)
+		if err != nil {
+			return PrivateKey{}, err
+		}
+		algo = parsed
+	}
+	address, err := types.ParseAddressHex(k.Address)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	salt, err := hex.DecodeString(k.Crypto.KDFParams.Salt)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid salt: %w", err)
+	}
+	nonce, err := hex.DecodeString(k.Crypto.Nonce)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid nonce: %w", err)
+	}
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	params := k.Crypto.KDFParams
+	if err := validateScryptParams(params.N, params.R, params.P, params.DKLen); err != nil {
+		return PrivateKey{}, err
+	}
+	derivedKey, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	gcm, err := newGCM(derivedKey)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return PrivateKey{}, fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+	plain, err := gcm.Open(nil, nonce, cipherText, address.ToSlice())
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("could not decrypt key with given password")
+	}
+	key, err := NewPrivateKey(algo, plain)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	if key.PublicKey().Address() != address {
+		return PrivateKey{}, fmt.Errorf("key address mismatch, expected %s, got %s", address, key.PublicKey().Address())
+	}
+	return key, nil
+}
+
+// validateScryptParams 检查 scrypt 参数是否在允许的范围内：N 是大于 1 且不超过 maxScryptN 的 2 的幂，
+// R 和 P 分别不超过 maxScryptR 和 maxScryptP，派生密钥的长度必须是 AES-256 密钥的长度。
+func validateScryptParams(n, r, p, dkLen int) error {
+	if n <= 1 || n > maxScryptN || n&(n-1) != 0 {
+		return fmt.Errorf("scrypt n %d must be a power of two in (1, %d]", n, maxScryptN)
+	}
+	if r < 1 || r > maxScryptR {
+		return fmt.Errorf("scrypt r %d out of range [1, %d]", r, maxScryptR)
+	}
+	if p < 1 || p > maxScryptP {
+		return fmt.Errorf("scrypt p %d out of range [1, %d]", p, maxScryptP)
+	}
+	if dkLen != scryptDKLen {
+		return fmt.Errorf("scrypt dklen %d should be %d", dkLen, scryptDKLen)
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyStore 管理一个目录下的加密密钥文件，每个地址对应一个文件。
+type KeyStore struct {
+	dir     string
+	scryptN int
+	scryptP int
+}
+
+// NewKeyStore 创建一个以 dir 为存储目录的 KeyStore，目录不存在时会被创建。
+//
+// 参数:
+//
+//	dir - 密钥文件目录。
+//	scryptN, scryptP - 加密新密钥时使用的 scrypt 参数。
+//
+// 返回值:
+//
+//	*KeyStore - 新的 KeyStore 实例。
+//	error - 创建目录失败时返回错误。
+func NewKeyStore(dir string, scryptN, scryptP int) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &KeyStore{
+		dir:     dir,
+		scryptN: scryptN,
+		scryptP: scryptP,
+	}, nil
+}
+
+func (ks *KeyStore) path(address types.Address) string {
+	return filepath.Join(ks.dir, address.String()+keystoreExt)
+}
+
+// NewKey 生成一个新的私钥并以口令加密保存，返回其地址。
+func (ks *KeyStore) NewKey(password string) (types.Address, error) {
+	return ks.Store(GeneratePrivateKey(), password)
+}
+
+// Store 使用口令加密私钥并写入密钥目录。
+//
+// 参数:
+//
+//	key - 需要保存的私钥。
+//	password - 口令。
+//
+// 返回值:
+//
+//	types.Address - 私钥对应的地址。
+//	error - 该地址已存在或写入失败时返回错误。
+func (ks *KeyStore) Store(key PrivateKey, password string) (types.Address, error) {
+	address := key.PublicKey().Address()
+	if ks.Has(address) {
+		return address, fmt.Errorf("key with address %s already exists", address)
+	}
+	data, err := EncryptKey(key, password, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return address, err
+	}
+	// 先写入临时文件再发布，避免留下不完整的密钥文件
+	tmp, err := os.CreateTemp(ks.dir, "."+address.String()+".tmp")
+	if err != nil {
+		return address, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return address, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return address, err
+	}
+	if err := tmp.Close(); err != nil {
+		return address, err
+	}
+	// 使用硬链接发布密钥文件：目标已存在时 Link 失败，而 Rename 会覆盖，
+	// 因此并发写入同一地址或在检查之后出现的文件不会被替换
+	if err := os.Link(tmp.Name(), ks.path(address)); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return address, fmt.Errorf("key with address %s already exists", address)
+		}
+		return address, err
+	}
+	return address, nil
+}
+
+// Load 读取并使用口令解密指定地址的私钥。
+func (ks *KeyStore) Load(address types.Address, password string) (PrivateKey, error) {
+	data, err := os.ReadFile(ks.path(address))
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return DecryptKey(data, password)
+}
+
+// Has 判断密钥目录中是否存在指定地址的密钥文件。
+func (ks *KeyStore) Has(address types.Address) bool {
+	_, err := os.Stat(ks.path(address))
+	return err == nil
+}
+
+// Delete 在口令校验通过后删除指定地址的密钥文件。
+func (ks *KeyStore) Delete(address types.Address, password string) error {
+	if _, err := ks.Load(address, password); err != nil {
+		return err
+	}
+	return os.Remove(ks.path(address))
+}
+
+// Addresses 列出密钥目录中所有密钥的地址，按字典序排列。
+// 无法解析的文件会被忽略。
+func (ks *KeyStore) Addresses() ([]types.Address, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	addresses := []types.Address{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, keystoreExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(ks.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		k := encryptedKeyJSON{}
+		if err := json.Unmarshal(data, &k); err != nil {
+			continue
+		}
+		address, err := types.ParseAddressHex(k.Address)
+		if err != nil {
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].String() < addresses[j].String()
+	})
+	return addresses, nil
+}
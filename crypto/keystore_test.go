@@ -0,0 +1,145 @@
+package crypto
+
+import (
+	"MyChain/types"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestEncryptKey_DecryptKey(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	data, err := EncryptKey(privateKey, "secret", LightScryptN, LightScryptP)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), privateKey.Hex())
+
+	dec, err := DecryptKey(data, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey.Hex(), dec.Hex())
+
+	_, err = DecryptKey(data, "wrong")
+	assert.NotNil(t, err)
+}
+
+func TestDecryptKey_TamperedAddress(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	data, err := EncryptKey(privateKey, "secret", LightScryptN, LightScryptP)
+	assert.Nil(t, err)
+
+	k := encryptedKeyJSON{}
+	assert.Nil(t, json.Unmarshal(data, &k))
+	k.Address = GeneratePrivateKey().PublicKey().Address().String()
+	data, err = json.Marshal(k)
+	assert.Nil(t, err)
+
+	_, err = DecryptKey(data, "secret")
+	assert.NotNil(t, err)
+}
+
+func TestDecryptKey_ScryptParams(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	data, err := EncryptKey(privateKey, "secret", LightScryptN, LightScryptP)
+	assert.Nil(t, err)
+
+	invalid := []func(p *scryptParamsJSON){
+		func(p *scryptParamsJSON) { p.N = maxScryptN << 1 },
+		func(p *scryptParamsJSON) { p.N = 1 << 30 },
+		func(p *scryptParamsJSON) { p.N = LightScryptN + 1 },
+		func(p *scryptParamsJSON) { p.N = 0 },
+		func(p *scryptParamsJSON) { p.R = maxScryptR + 1 },
+		func(p *scryptParamsJSON) { p.R = 0 },
+		func(p *scryptParamsJSON) { p.P = maxScryptP + 1 },
+		func(p *scryptParamsJSON) { p.P = -1 },
+		func(p *scryptParamsJSON) { p.DKLen = 1 << 30 },
+		func(p *scryptParamsJSON) { p.DKLen = 16 },
+	}
+	for i, modify := range invalid {
+		k := encryptedKeyJSON{}
+		assert.Nil(t, json.Unmarshal(data, &k))
+		modify(&k.Crypto.KDFParams)
+		tampered, err := json.Marshal(k)
+		assert.Nil(t, err)
+		_, err = DecryptKey(tampered, "secret")
+		assert.NotNil(t, err, "params %d", i)
+	}
+
+	_, err = EncryptKey(privateKey, "secret", maxScryptN<<1, LightScryptP)
+	assert.NotNil(t, err)
+}
+
+func TestKeyStore(t *testing.T) {
+	ks, err := NewKeyStore(t.TempDir(), LightScryptN, LightScryptP)
+	assert.Nil(t, err)
+
+	addresses, err := ks.Addresses()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(addresses))
+
+	privateKey := GeneratePrivateKey()
+	address, err := ks.Store(privateKey, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey.PublicKey().Address(), address)
+	assert.True(t, ks.Has(address))
+
+	_, err = ks.Store(privateKey, "secret")
+	assert.NotNil(t, err)
+
+	other, err := ks.NewKey("other")
+	assert.Nil(t, err)
+
+	addresses, err = ks.Addresses()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, addresses, []types.Address{address, other})
+
+	loaded, err := ks.Load(address, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey.Hex(), loaded.Hex())
+
+	info, err := os.Stat(filepath.Join(ks.dir, address.String()+keystoreExt))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.NotNil(t, ks.Delete(address, "wrong"))
+	assert.Nil(t, ks.Delete(address, "secret"))
+	assert.False(t, ks.Has(address))
+}
+
+func TestKeyStore_Store_Concurrent(t *testing.T) {
+	ks, err := NewKeyStore(t.TempDir(), LightScryptN, LightScryptP)
+	assert.Nil(t, err)
+	privateKey := GeneratePrivateKey()
+
+	// 并发保存同一私钥，只有一次成功，已发布的文件不会被其他口令的文件覆盖
+	const n = 8
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = ks.Store(privateKey, strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		if err == nil {
+			assert.Equal(t, -1, winner)
+			winner = i
+		}
+	}
+	assert.NotEqual(t, -1, winner)
+	loaded, err := ks.Load(privateKey.PublicKey().Address(), strconv.Itoa(winner))
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey.Hex(), loaded.Hex())
+
+	// 不留下临时文件
+	entries, err := os.ReadDir(ks.dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+}
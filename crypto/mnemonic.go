@@ -0,0 +1,197 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordlist_english.txt 是 BIP-39 规范的英文单词表，共 2048 个单词。
+//
+//go:embed wordlist_english.txt
+var englishWordList string
+
+var (
+	wordList  = strings.Split(strings.TrimSpace(englishWordList), "\n")
+	wordIndex = make(map[string]int, len(wordList))
+)
+
+func init() {
+	for i, word := range wordList {
+		wordIndex[word] = i
+	}
+}
+
+const (
+	// 助记词种子派生参数，与 BIP-39 一致
+	mnemonicSeedIterations = 2048
+	mnemonicSeedLength     = 64
+	bitsPerWord            = 11
+)
+
+// NewEntropy 生成指定比特数的随机熵，比特数必须是 128 到 256 之间 32 的倍数。
+func NewEntropy(bitSize int) ([]byte, error) {
+	if err := validateEntropyBits(bitSize); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bitSize/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// GenerateMnemonic 生成指定熵比特数的随机助记词，128 比特对应 12 个单词，256 比特对应 24 个单词。
+func GenerateMnemonic(bitSize int) (string, error) {
+	entropy, err := NewEntropy(bitSize)
+	if err != nil {
+		return "", err
+	}
+	return NewMnemonic(entropy)
+}
+
+// NewMnemonic 按照 BIP-39 将熵编码为助记词。
+// 熵后附加 SHA-256 摘要的前 len(entropy)/4 比特作为校验和，再每 11 比特映射为一个单词。
+//
+// 参数:
+//
+//	entropy - 熵，长度为 16 到 32 字节且为 4 的倍数。
+//
+// 返回值:
+//
+//	string - 以空格分隔的助记词。
+//	error - 熵长度不合法时返回错误。
+func NewMnemonic(entropy []byte) (string, error) {
+	bitSize := len(entropy) * 8
+	if err := validateEntropyBits(bitSize); err != nil {
+		return "", err
+	}
+	checksumBits := bitSize / 32
+	hash := sha256.Sum256(entropy)
+
+	// 将熵和校验和拼接为一个大整数，便于按 11 比特切分
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	wordCount := (bitSize + checksumBits) / bitsPerWord
+	words := make([]string, wordCount)
+	mask := big.NewInt(1<<bitsPerWord - 1)
+	index := new(big.Int)
+	for i := wordCount - 1; i >= 0; i-- {
+		index.And(data, mask)
+		words[i] = wordList[index.Int64()]
+		data.Rsh(data, bitsPerWord)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy 将助记词解码为熵，并严格校验单词和校验和。
+//
+// 参数:
+//
+//	mnemonic - 以空格分隔的助记词。
+//
+// 返回值:
+//
+//	[]byte - 助记词对应的熵。
+//	error - 单词数量不合法、包含单词表之外的单词或校验和错误时返回错误。
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("invalid mnemonic word count %d", len(words))
+	}
+
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word %q", word)
+		}
+		data.Lsh(data, bitsPerWord)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+
+	totalBits := len(words) * bitsPerWord
+	checksumBits := totalBits / 33
+	bitSize := totalBits - checksumBits
+
+	checksum := new(big.Int).And(data, big.NewInt(1<<checksumBits-1))
+	data.Rsh(data, uint(checksumBits))
+	entropy := data.FillBytes(make([]byte, bitSize/8))
+
+	hash := sha256.Sum256(entropy)
+	if checksum.Int64() != int64(hash[0]>>(8-checksumBits)) {
+		return nil, fmt.Errorf("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic 校验助记词的单词和校验和是否有效。
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed 校验助记词后，使用 PBKDF2-HMAC-SHA512 将助记词和可选口令转换为 64 字节种子。
+//
+// 参数:
+//
+//	mnemonic - 以空格分隔的助记词。
+//	passphrase - 可选口令，不同口令得到不同的种子。
+//
+// 返回值:
+//
+//	[]byte - 64 字节的种子，可用于 NewMasterKey。
+//	error - 助记词无效时返回错误。
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(normalized), []byte(salt), mnemonicSeedIterations, mnemonicSeedLength, sha512.New), nil
+}
+
+// PrivateKeyFromMnemonic 由助记词和口令得到种子，再按派生路径确定性地派生私钥。
+//
+// 参数:
+//
+//	algo - 派生私钥使用的签名算法。
+//	mnemonic - 以空格分隔的助记词。
+//	passphrase - 可选口令。
+//	path - 派生路径，如 m/44'/0'/0'。
+//
+// 返回值:
+//
+//	PrivateKey - 派生得到的私钥。
+//	error - 助记词或派生路径无效时返回错误。
+func PrivateKeyFromMnemonic(algo Algorithm, mnemonic, passphrase, path string) (PrivateKey, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	master, err := NewMasterKey(algo, seed)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	key, err := master.Derive(path)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return key.PrivateKey()
+}
+
+func validateEntropyBits(bitSize int) error {
+	if bitSize < 128 || bitSize > 256 || bitSize%32 != 0 {
+		return fmt.Errorf("invalid entropy bit size %d", bitSize)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type mnemonicTestVector struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}
+
+// BIP-39 官方测试向量，口令为 TREZOR。
+var mnemonicVectors = []mnemonicTestVector{
+	{
+		entropy:  "00000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		seed:     "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		entropy:  "808080808080808080808080808080808080808080808080",
+		mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter always",
+		seed:     "107d7c02a5aa6f38c58083ff74f04c607c2d2c0ecc55501dadd72d025b751bc27fe913ffb796f841c49b1d33b610cf0e91d3aa239027f5e99fe4ce9e5088cd65",
+	},
+	{
+		entropy:  "b63a9c59a6e641f288ebc103017f1da9f8290b3da6bdef7b",
+		mnemonic: "renew stay biology evidence goat welcome casual join adapt armor shuffle fault little machine walk stumble urge swap",
+		seed:     "9248d83e06f4cd98debf5b6f010542760df925ce46cf38a1bdb4e4de7d21f5c39366941c69e1bdbf2966e0f6e6dbece898a0e2f0a4c2b3e640953dfe8b7bbdc5",
+	},
+	{
+		entropy:  "3e141609b97933b66a060dcddc71fad1d91677db872031e85f4c015c5e7e8982",
+		mnemonic: "dignity pass list indicate nasty swamp pool script soccer toe leaf photo multiply desk host tomato cradle drill spread actor shine dismiss champion exotic",
+		seed:     "ff7f3184df8696d8bef94b6c03114dbee0ef89ff938712301d27ed8336ca89ef9635da20af07d4175f2bf5f3de130f39c9d9e8dd0472489c19b1a020a940da67",
+	},
+}
+
+func TestMnemonic_Vectors(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		entropy, err := hex.DecodeString(v.entropy)
+		assert.Nil(t, err)
+
+		mnemonic, err := NewMnemonic(entropy)
+		assert.Nil(t, err)
+		assert.Equal(t, v.mnemonic, mnemonic)
+
+		dec, err := MnemonicToEntropy(mnemonic)
+		assert.Nil(t, err)
+		assert.Equal(t, entropy, dec)
+
+		seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+		assert.Nil(t, err)
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+}
+
+func TestMnemonic_Invalid(t *testing.T) {
+	invalid := []string{
+		// 单词数量错误
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow yellow",
+		// 单词不在单词表中
+		"letter advice cage absurd amount doctor acoustic avoid letter advice caged above",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo, wrong",
+		// 校验和错误
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo why",
+		"jello better achieve collect unaware mountain thought cargo oxygen act hood bridge",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon letter",
+		"",
+	}
+	for _, mnemonic := range invalid {
+		assert.NotNil(t, ValidateMnemonic(mnemonic), mnemonic)
+		_, err := MnemonicToSeed(mnemonic, "")
+		assert.NotNil(t, err, mnemonic)
+	}
+
+	_, err := NewMnemonic(make([]byte, 15))
+	assert.NotNil(t, err)
+	_, err = GenerateMnemonic(100)
+	assert.NotNil(t, err)
+}
+
+func TestGenerateMnemonic(t *testing.T) {
+	for _, bitSize := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := GenerateMnemonic(bitSize)
+		assert.Nil(t, err)
+		assert.Nil(t, ValidateMnemonic(mnemonic))
+	}
+}
+
+func TestPrivateKeyFromMnemonic(t *testing.T) {
+	mnemonic := mnemonicVectors[0].mnemonic
+	key1, err := PrivateKeyFromMnemonic(AlgoP256, mnemonic, "TREZOR", "m/44'/0'/0'/0/0")
+	assert.Nil(t, err)
+	key2, err := PrivateKeyFromMnemonic(AlgoP256, mnemonic, "TREZOR", "m/44'/0'/0'/0/0")
+	assert.Nil(t, err)
+	assert.Equal(t, key1.Hex(), key2.Hex())
+
+	other, err := PrivateKeyFromMnemonic(AlgoP256, mnemonic, "", "m/44'/0'/0'/0/0")
+	assert.Nil(t, err)
+	assert.NotEqual(t, key1.Hex(), other.Hex())
+
+	edKey, err := PrivateKeyFromMnemonic(AlgoEd25519, mnemonic, "TREZOR", "m/44'/0'/0'")
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoEd25519, edKey.Algorithm())
+
+	_, err = PrivateKeyFromMnemonic(AlgoP256, "abandon", "", "m/0")
+	assert.NotNil(t, err)
+}
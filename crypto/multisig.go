@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"MyChain/types"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+)
+
+const (
+	// MaxMultisigMembers 是多签账户成员数量的上限
+	MaxMultisigMembers = 20
+	// multisigAddressPrefix 是计算多签地址时的前缀，与单个公钥的地址区分
+	multisigAddressPrefix = "multisig"
+)
+
+// MultisigAccount 是由多个成员公钥组成、需要 Threshold 个成员签名才能授权的账户。
+// 成员按 (算法, 公钥编码) 排序，因此成员的顺序不影响账户地址。
+type MultisigAccount struct {
+	Threshold uint8
+	Members   []PublicKey
+}
+
+// NewMultisigAccount 创建需要 threshold 个成员签名的多签账户。
+//
+// 参数:
+//
+//	threshold - 授权所需的最少签名数量。
+//	members - 成员公钥，不能重复。
+//
+// 返回值:
+//
+//	*MultisigAccount - 成员已排序的多签账户。
+//	error - 阈值为 0 或超过成员数量、成员数量超过上限、成员公钥无效或重复时返回错误。
+func NewMultisigAccount(threshold int, members []PublicKey) (*MultisigAccount, error) {
+	if threshold <= 0 || threshold > len(members) {
+		return nil, fmt.Errorf("multisig threshold %d out of range [1, %d]", threshold, len(members))
+	}
+	if err := validateMembers(members); err != nil {
+		return nil, err
+	}
+	m := &MultisigAccount{
+		Threshold: uint8(threshold),
+		Members:   append([]PublicKey(nil), members...),
+	}
+	sort.Slice(m.Members, func(i, j int) bool {
+		return bytes.Compare(memberBytes(m.Members[i]), memberBytes(m.Members[j])) < 0
+	})
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate 检查多签账户的阈值和成员是否有效，成员公钥必须有效，且按 NewMultisigAccount 的顺序排列、不重复。
+// 解码得到的多签账户必须先通过该检查才能调用 Address。
+func (m *MultisigAccount) Validate() error {
+	if len(m.Members) == 0 || len(m.Members) > MaxMultisigMembers {
+		return fmt.Errorf("multisig has %d members, must be in [1, %d]", len(m.Members), MaxMultisigMembers)
+	}
+	if m.Threshold == 0 || int(m.Threshold) > len(m.Members) {
+		return fmt.Errorf("multisig threshold %d out of range [1, %d]", m.Threshold, len(m.Members))
+	}
+	if err := validateMembers(m.Members); err != nil {
+		return err
+	}
+	for i := 1; i < len(m.Members); i++ {
+		if bytes.Compare(memberBytes(m.Members[i-1]), memberBytes(m.Members[i])) >= 0 {
+			return fmt.Errorf("multisig members are not sorted or contain duplicates at index %d", i)
+		}
+	}
+	return nil
+}
+
+// Address 返回多签账户的地址，即 sha256("multisig" || 阈值 || 每个成员的算法和公钥编码) 的后 20 字节。
+func (m *MultisigAccount) Address() types.Address {
+	buf := append([]byte(multisigAddressPrefix), m.Threshold)
+	for _, member := range m.Members {
+		b := memberBytes(member)
+		buf = append(buf, byte(len(b)))
+		buf = append(buf, b...)
+	}
+	hash := sha256.Sum256(buf)
+	return types.MustAddressFromBytes(hash[len(hash)-20:])
+}
+
+// Verify 验证签名是否恰好来自 Threshold 个不同的成员，且签名按签名者在 Members 中的顺序排列。
+// 签名的数量和顺序是唯一的，因此包含签名的交易哈希不能在不改变签名者的情况下被第三方修改。
+//
+// 参数:
+//
+//	digest - 被签名的数据摘要。
+//	sigs - 成员的签名，每个签名都必须能恢复出一个成员的公钥。
+//
+// 返回值:
+//
+//	error - 多签账户无效、签名数量不等于阈值、签名无效、签名者不是成员或签名者未按成员顺序严格递增时返回错误。
+func (m *MultisigAccount) Verify(digest []byte, sigs []*Signature) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if len(sigs) != int(m.Threshold) {
+		return fmt.Errorf("multisig requires exactly %d signatures, got %d", m.Threshold, len(sigs))
+	}
+	last := -1
+	for i, sig := range sigs {
+		j, err := m.SignerIndex(digest, sig)
+		if err != nil {
+			return fmt.Errorf("invalid signature at index %d: %w", i, err)
+		}
+		if j <= last {
+			return fmt.Errorf("signature at index %d from member %d is not in member order", i, j)
+		}
+		last = j
+	}
+	return nil
+}
+
+// SignerIndex 从签名中恢复签名者，返回其在 Members 中的下标。
+//
+// 参数:
+//
+//	digest - 被签名的数据摘要。
+//	sig - 成员的签名。
+//
+// 返回值:
+//
+//	int - 签名者在 Members 中的下标。
+//	error - 签名无效或签名者不是成员时返回错误。
+func (m *MultisigAccount) SignerIndex(digest []byte, sig *Signature) (int, error) {
+	pubKey, err := RecoverPublicKey(digest, sig)
+	if err != nil {
+		return -1, err
+	}
+	j := m.memberIndex(pubKey)
+	if j < 0 {
+		return -1, fmt.Errorf("signer %s is not a multisig member", pubKey.Address())
+	}
+	return j, nil
+}
+
+// IsMember 判断公钥是否为多签账户的成员。
+func (m *MultisigAccount) IsMember(pubKey PublicKey) bool {
+	return m.memberIndex(pubKey) >= 0
+}
+
+func (m *MultisigAccount) memberIndex(pubKey PublicKey) int {
+	b := memberBytes(pubKey)
+	for i, member := range m.Members {
+		if bytes.Equal(memberBytes(member), b) {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateMembers 检查每个成员公钥是否有效。
+func validateMembers(members []PublicKey) error {
+	for i, member := range members {
+		if !member.IsValid() {
+			return fmt.Errorf("multisig member %d has an invalid public key", i)
+		}
+	}
+	return nil
+}
+
+// memberBytes 返回成员公钥的编码，即算法后接公钥的字节表示，用于排序、比较和计算地址。
+// 无效的公钥只编码算法，不会与任何有效成员相等。
+func memberBytes(k PublicKey) []byte {
+	if !k.IsValid() {
+		return []byte{byte(k.Algo)}
+	}
+	return append([]byte{byte(k.Algo)}, k.ToSlice()...)
+}
@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+// multisigKeys 生成 n 个成员私钥，交替使用 P-256 和 Ed25519。
+func multisigKeys(t *testing.T, n int) ([]PrivateKey, []PublicKey) {
+	keys := make([]PrivateKey, n)
+	pubKeys := make([]PublicKey, n)
+	for i := range keys {
+		algo := AlgoP256
+		if i%2 == 1 {
+			algo = AlgoEd25519
+		}
+		key, err := GenerateKey(algo)
+		assert.Nil(t, err)
+		keys[i], pubKeys[i] = key, key.PublicKey()
+	}
+	return keys, pubKeys
+}
+
+func TestNewMultisigAccount(t *testing.T) {
+	_, pubKeys := multisigKeys(t, 3)
+	m, err := NewMultisigAccount(2, pubKeys)
+	assert.Nil(t, err)
+	assert.Nil(t, m.Validate())
+
+	// 成员的顺序不影响地址，阈值和成员影响地址
+	reversed, err := NewMultisigAccount(2, []PublicKey{pubKeys[2], pubKeys[1], pubKeys[0]})
+	assert.Nil(t, err)
+	assert.Equal(t, m.Address(), reversed.Address())
+	other, err := NewMultisigAccount(3, pubKeys)
+	assert.Nil(t, err)
+	assert.NotEqual(t, m.Address(), other.Address())
+	other, err = NewMultisigAccount(2, pubKeys[:2])
+	assert.Nil(t, err)
+	assert.NotEqual(t, m.Address(), other.Address())
+	// 只有一个成员的多签地址与该成员本身的地址不同
+	single, err := NewMultisigAccount(1, pubKeys[:1])
+	assert.Nil(t, err)
+	assert.NotEqual(t, pubKeys[0].Address(), single.Address())
+
+	_, err = NewMultisigAccount(0, pubKeys)
+	assert.NotNil(t, err)
+	_, err = NewMultisigAccount(4, pubKeys)
+	assert.NotNil(t, err)
+	_, err = NewMultisigAccount(1, []PublicKey{pubKeys[0], pubKeys[0]})
+	assert.NotNil(t, err)
+	_, tooMany := multisigKeys(t, MaxMultisigMembers+1)
+	_, err = NewMultisigAccount(1, tooMany)
+	assert.NotNil(t, err)
+}
+
+func TestMultisigAccount_Verify(t *testing.T) {
+	keys, pubKeys := multisigKeys(t, 3)
+	m, err := NewMultisigAccount(2, pubKeys)
+	assert.Nil(t, err)
+	digest := sha256.Sum256([]byte("hello"))
+	sign := func(key PrivateKey) *Signature {
+		sig, err := key.Sign(digest[:])
+		assert.Nil(t, err)
+		return sig
+	}
+
+	// 按成员顺序排列私钥
+	sort.Slice(keys, func(i, j int) bool {
+		return m.memberIndex(keys[i].PublicKey()) < m.memberIndex(keys[j].PublicKey())
+	})
+
+	assert.NotNil(t, m.Verify(digest[:], []*Signature{sign(keys[0])}))
+	assert.Nil(t, m.Verify(digest[:], []*Signature{sign(keys[0]), sign(keys[2])}))
+	assert.Nil(t, m.Verify(digest[:], []*Signature{sign(keys[1]), sign(keys[2])}))
+
+	// 签名必须恰好达到阈值且按成员顺序排列，第三方不能改变签名集合的编码
+	assert.NotNil(t, m.Verify(digest[:], []*Signature{sign(keys[2]), sign(keys[0])}))
+	assert.NotNil(t, m.Verify(digest[:], []*Signature{sign(keys[0]), sign(keys[1]), sign(keys[2])}))
+	// 同一成员重复签名不能凑够阈值
+	assert.NotNil(t, m.Verify(digest[:], []*Signature{sign(keys[0]), sign(keys[0])}))
+	// 非成员的签名
+	outsider, _ := multisigKeys(t, 1)
+	assert.NotNil(t, m.Verify(digest[:], []*Signature{sign(keys[0]), sign(outsider[0])}))
+	// 签名与数据不符
+	other := sha256.Sum256([]byte("other"))
+	assert.NotNil(t, m.Verify(other[:], []*Signature{sign(keys[0]), sign(keys[1])}))
+
+	index, err := m.SignerIndex(digest[:], sign(keys[1]))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, index)
+	_, err = m.SignerIndex(digest[:], sign(outsider[0]))
+	assert.NotNil(t, err)
+}
+
+func TestMultisigAccount_Gob(t *testing.T) {
+	_, pubKeys := multisigKeys(t, 3)
+	m, err := NewMultisigAccount(2, pubKeys)
+	assert.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, gob.NewEncoder(buf).Encode(m))
+	dec := new(MultisigAccount)
+	assert.Nil(t, gob.NewDecoder(buf).Decode(dec))
+	assert.Nil(t, dec.Validate())
+	assert.Equal(t, m.Address(), dec.Address())
+}
+
+func TestMultisigAccount_InvalidMember(t *testing.T) {
+	_, pubKeys := multisigKeys(t, 2)
+	_, err := NewMultisigAccount(1, append(pubKeys, PublicKey{}))
+	assert.NotNil(t, err)
+
+	m := &MultisigAccount{Threshold: 1, Members: []PublicKey{{}}}
+	buf := &bytes.Buffer{}
+	assert.Nil(t, gob.NewEncoder(buf).Encode(m))
+	dec := new(MultisigAccount)
+	assert.Nil(t, gob.NewDecoder(buf).Decode(dec))
+	assert.NotPanics(t, func() {
+		assert.NotNil(t, dec.Validate())
+		assert.NotNil(t, dec.Verify([]byte("digest"), nil))
+		assert.False(t, dec.IsMember(pubKeys[0]))
+	})
+}
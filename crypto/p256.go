@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+)
+
+var (
+	// curveOrder 是 P-256 曲线的阶 N。
+	curveOrder = elliptic.P256().Params().N
+	// curveHalfOrder 是 N/2，S 大于该值的签名被视为非规范签名。
+	curveHalfOrder = new(big.Int).Rsh(curveOrder, 1)
+)
+
+// p256Scheme 实现基于 P-256 曲线的 ECDSA 签名，签名为低 S 形式并带有恢复 ID。
+type p256Scheme struct{}
+
+func (p256Scheme) Algorithm() Algorithm {
+	return AlgoP256
+}
+
+func (p256Scheme) GenerateKey() (PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return PrivateKey{algo: AlgoP256, key: key}, nil
+}
+
+func (p256Scheme) PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	return PrivateKeyFromBytes(b)
+}
+
+func (p256Scheme) PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	return PublicKeyFromBytes(b)
+}
+
+// Sign 使用ECDSA算法对数据签名，并将 S 规范化为低 S 形式、计算恢复 ID。
+func (p256Scheme) Sign(k PrivateKey, data []byte) (*Signature, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, k.key, data)
+	if err != nil {
+		return nil, err
+	}
+	// 将 S 规范化为低 S 形式，消除签名的可延展性
+	if s.Cmp(curveHalfOrder) > 0 {
+		s = new(big.Int).Sub(curveOrder, s)
+	}
+	sig := &Signature{Algo: AlgoP256, R: r, S: s}
+	// 计算恢复 ID，使验证方无需公钥即可恢复签名者
+	v, err := recoveryID(k.PublicKey(), data, sig)
+	if err != nil {
+		return nil, err
+	}
+	sig.V = v
+	return sig, nil
+}
+
+func (p256Scheme) Verify(pubKey PublicKey, data []byte, sig *Signature) bool {
+	// 拒绝高 S 签名，防止第三方通过 S -> N-S 构造另一个有效签名
+	if pubKey.Key == nil || !p256Canonical(sig) {
+		return false
+	}
+	return ecdsa.Verify(pubKey.Key, data, sig.R, sig.S)
+}
+
+func (p256Scheme) Recover(data []byte, sig *Signature) (PublicKey, error) {
+	return recoverP256(data, sig)
+}
+
+// p256Canonical 判断签名是否为规范形式，即 0 < R < N 且 0 < S <= N/2。
+func p256Canonical(s *Signature) bool {
+	if s.R == nil || s.S == nil {
+		return false
+	}
+	if s.R.Sign() <= 0 || s.R.Cmp(curveOrder) >= 0 {
+		return false
+	}
+	return s.S.Sign() > 0 && s.S.Cmp(curveHalfOrder) <= 0
+}
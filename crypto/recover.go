@@ -0,0 +1,157 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+)
+
+// RecoverableSignatureLength 是带恢复 ID 的签名序列化长度，即 R || S || V。
+const RecoverableSignatureLength = SignatureLength + 1
+
+// RecoverableBytes 将签名序列化为可恢复签名者的格式。
+// P-256 签名为 65 字节：R 和 S 各 32 字节，最后 1 字节为恢复 ID V；
+// Ed25519 签名为 64 字节签名后接 32 字节签名者公钥。
+// 该方法可以用于未经校验的签名：空签名编码为 65 字节的零，格式错误的分量按 Bytes 的规则编码，不会 panic。
+func (s *Signature) RecoverableBytes() []byte {
+	if s == nil {
+		return make([]byte, RecoverableSignatureLength)
+	}
+	if s.Algo == AlgoEd25519 {
+		return append(s.Bytes(), s.Signer...)
+	}
+	return append(s.Bytes(), s.V)
+}
+
+// ValidateRecoverable 检查签名是否为可以序列化和恢复签名者的规范形式，
+// 即签名是规范的且 P-256 签名的恢复 ID 不超过 3。解码自网络的签名在计算哈希或验证之前必须先通过该检查。
+func (s *Signature) ValidateRecoverable() error {
+	if s == nil {
+		return fmt.Errorf("signature is nil")
+	}
+	if !s.IsCanonical() {
+		return fmt.Errorf("signature is not canonical")
+	}
+	if s.Algo == AlgoP256 && s.V > 3 {
+		return fmt.Errorf("invalid recovery id %d", s.V)
+	}
+	return nil
+}
+
+// RecoverableSignatureFromBytes 从 65 字节的 R || S || V 格式解析 P-256 签名。
+func RecoverableSignatureFromBytes(b []byte) (*Signature, error) {
+	if len(b) != RecoverableSignatureLength {
+		return nil, fmt.Errorf("given bytes with length %d should be %d", len(b), RecoverableSignatureLength)
+	}
+	sig, err := SignatureFromBytes(b[:SignatureLength])
+	if err != nil {
+		return nil, err
+	}
+	sig.V = b[SignatureLength]
+	if sig.V > 3 {
+		return nil, fmt.Errorf("invalid recovery id %d", sig.V)
+	}
+	return sig, nil
+}
+
+// RecoverPublicKey 根据签名和被签名的摘要恢复出签名者的公钥，恢复方式由签名算法决定。
+//
+// 参数:
+//
+//	digest - 被签名的数据摘要，与 Sign 时传入的数据一致。
+//	sig - 带恢复 ID 的签名。
+//
+// 返回值:
+//
+//	PublicKey - 恢复出的公钥。
+//	error - 签名不规范、恢复 ID 无效或无法恢复出有效公钥时返回错误。
+func RecoverPublicKey(digest []byte, sig *Signature) (PublicKey, error) {
+	if sig == nil {
+		return PublicKey{}, fmt.Errorf("signature is nil")
+	}
+	s, err := LookupScheme(sig.Algo)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return s.Recover(digest, sig)
+}
+
+// recoverP256 从 P-256 签名恢复公钥。
+// 计算方法为 Q = r^-1 * (s*R - e*G)，其中 R 是由 r 和恢复 ID 确定的曲线点。
+func recoverP256(digest []byte, sig *Signature) (PublicKey, error) {
+	if !p256Canonical(sig) {
+		return PublicKey{}, fmt.Errorf("signature is not canonical")
+	}
+	if sig.V > 3 {
+		return PublicKey{}, fmt.Errorf("invalid recovery id %d", sig.V)
+	}
+	curve := elliptic.P256()
+	params := curve.Params()
+
+	// 恢复 ID 的第 2 位表示 R.x = r + N，第 1 位表示 R.y 的奇偶性
+	x := new(big.Int).Set(sig.R)
+	if sig.V&2 != 0 {
+		x.Add(x, params.N)
+		if x.Cmp(params.P) >= 0 {
+			return PublicKey{}, fmt.Errorf("invalid recovery id %d", sig.V)
+		}
+	}
+	compressed := make([]byte, PublicKeyCompressedLength)
+	compressed[0] = 2 + sig.V&1
+	x.FillBytes(compressed[1:])
+	rx, ry := elliptic.UnmarshalCompressed(curve, compressed)
+	if rx == nil {
+		return PublicKey{}, fmt.Errorf("invalid signature: R is not on curve")
+	}
+
+	// u1 = -e * r^-1 mod N, u2 = s * r^-1 mod N
+	e := hashToInt(digest)
+	rInv := new(big.Int).ModInverse(sig.R, params.N)
+	u1 := new(big.Int).Mul(e, rInv)
+	u1.Neg(u1).Mod(u1, params.N)
+	u2 := new(big.Int).Mul(sig.S, rInv)
+	u2.Mod(u2, params.N)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(rx, ry, u2.Bytes())
+	qx, qy := curve.Add(x1, y1, x2, y2)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return PublicKey{}, fmt.Errorf("invalid signature: recovered point at infinity")
+	}
+
+	pub := PublicKey{Key: &ecdsa.PublicKey{Curve: curve, X: qx, Y: qy}}
+	if !ecdsa.Verify(pub.Key, digest, sig.R, sig.S) {
+		return PublicKey{}, fmt.Errorf("invalid signature")
+	}
+	return pub, nil
+}
+
+// hashToInt 按照 ECDSA 的规则将摘要转换为整数：截取与曲线阶等长的最左侧比特。
+func hashToInt(hash []byte) *big.Int {
+	orderBits := curveOrder.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+	e := new(big.Int).SetBytes(hash)
+	if excess := len(hash)*8 - orderBits; excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+	return e
+}
+
+// recoveryID 计算能从签名恢复出给定公钥的恢复 ID。
+func recoveryID(pub PublicKey, digest []byte, sig *Signature) (byte, error) {
+	for v := byte(0); v < 4; v++ {
+		candidate := &Signature{R: sig.R, S: sig.S, V: v}
+		recovered, err := recoverP256(digest, candidate)
+		if err != nil {
+			continue
+		}
+		if recovered.Key.X.Cmp(pub.Key.X) == 0 && recovered.Key.Y.Cmp(pub.Key.Y) == 0 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("could not compute recovery id")
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestRecoverPublicKey(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		privateKey := GeneratePrivateKey()
+		digest := sha256.Sum256([]byte("hello"))
+
+		sign, err := privateKey.Sign(digest[:])
+		assert.Nil(t, err)
+
+		recovered, err := RecoverPublicKey(digest[:], sign)
+		assert.Nil(t, err)
+		assert.Equal(t, privateKey.PublicKey().Address(), recovered.Address())
+	}
+}
+
+func TestRecoverPublicKey_WrongDigest(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	sign, err := privateKey.Sign([]byte("hello"))
+	assert.Nil(t, err)
+
+	recovered, err := RecoverPublicKey([]byte("world"), sign)
+	if err == nil {
+		assert.NotEqual(t, privateKey.PublicKey().Address(), recovered.Address())
+	}
+
+	sign.V = 4
+	_, err = RecoverPublicKey([]byte("hello"), sign)
+	assert.NotNil(t, err)
+}
+
+func TestSignature_RecoverableBytes(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	msg := []byte("hello")
+	sign, err := privateKey.Sign(msg)
+	assert.Nil(t, err)
+
+	b := sign.RecoverableBytes()
+	assert.Equal(t, RecoverableSignatureLength, len(b))
+
+	dec, err := RecoverableSignatureFromBytes(b)
+	assert.Nil(t, err)
+	assert.Equal(t, sign.V, dec.V)
+
+	recovered, err := RecoverPublicKey(msg, dec)
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey.PublicKey().Address(), recovered.Address())
+
+	_, err = RecoverableSignatureFromBytes(b[:SignatureLength])
+	assert.NotNil(t, err)
+
+	// 空签名和格式错误的签名可以被序列化，但不能被解析
+	for _, sig := range []*Signature{nil, {S: big.NewInt(1), V: 1}} {
+		assert.NotPanics(t, func() {
+			b := sig.RecoverableBytes()
+			assert.Equal(t, RecoverableSignatureLength, len(b))
+			_, err := RecoverableSignatureFromBytes(b)
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package crypto
+
+import "fmt"
+
+// Algorithm 标识签名算法，同时记录在密钥和签名中。
+type Algorithm byte
+
+const (
+	// AlgoP256 是基于 NIST P-256 曲线的 ECDSA，作为零值即默认算法。
+	AlgoP256 Algorithm = iota
+	// AlgoEd25519 是 Ed25519 签名算法。
+	AlgoEd25519
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case AlgoP256:
+		return "p256"
+	case AlgoEd25519:
+		return "ed25519"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(a))
+	}
+}
+
+// AlgorithmFromString 根据名称解析签名算法。
+func AlgorithmFromString(s string) (Algorithm, error) {
+	for algo := range schemes {
+		if algo.String() == s {
+			return algo, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signature algorithm %q", s)
+}
+
+// Scheme 定义了一种签名算法需要实现的全部操作。
+// PrivateKey、PublicKey 和 Signature 的方法根据自身的算法标识分派到对应的 Scheme。
+type Scheme interface {
+	// Algorithm 返回该方案的算法标识。
+	Algorithm() Algorithm
+	// GenerateKey 生成一个随机私钥。
+	GenerateKey() (PrivateKey, error)
+	// PrivateKeyFromBytes 从原始字节解析私钥。
+	PrivateKeyFromBytes(b []byte) (PrivateKey, error)
+	// PublicKeyFromBytes 从原始字节解析公钥。
+	PublicKeyFromBytes(b []byte) (PublicKey, error)
+	// Sign 使用私钥对数据签名。
+	Sign(key PrivateKey, data []byte) (*Signature, error)
+	// Verify 使用公钥验证签名。
+	Verify(pubKey PublicKey, data []byte, sig *Signature) bool
+	// Recover 从签名中得到签名者公钥，并确认签名有效。
+	Recover(data []byte, sig *Signature) (PublicKey, error)
+}
+
+var schemes = map[Algorithm]Scheme{}
+
+func registerScheme(s Scheme) {
+	schemes[s.Algorithm()] = s
+}
+
+func init() {
+	registerScheme(p256Scheme{})
+	registerScheme(ed25519Scheme{})
+}
+
+// LookupScheme 返回给定算法对应的签名方案。
+func LookupScheme(algo Algorithm) (Scheme, error) {
+	s, ok := schemes[algo]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature algorithm %s", algo)
+	}
+	return s, nil
+}
+
+// GenerateKey 使用指定算法生成一个随机私钥。
+func GenerateKey(algo Algorithm) (PrivateKey, error) {
+	s, err := LookupScheme(algo)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return s.GenerateKey()
+}
+
+// NewPrivateKey 使用指定算法从原始字节解析私钥。
+func NewPrivateKey(algo Algorithm, b []byte) (PrivateKey, error) {
+	s, err := LookupScheme(algo)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	return s.PrivateKeyFromBytes(b)
+}
+
+// NewPublicKey 使用指定算法从原始字节解析公钥。
+func NewPublicKey(algo Algorithm, b []byte) (PublicKey, error) {
+	s, err := LookupScheme(algo)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return s.PublicKeyFromBytes(b)
+}
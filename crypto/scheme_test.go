@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEd25519_Sign_Verify(t *testing.T) {
+	privateKey, err := GenerateKey(AlgoEd25519)
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoEd25519, privateKey.Algorithm())
+	publicKey := privateKey.PublicKey()
+
+	msg := []byte("hello")
+	sign, err := privateKey.Sign(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoEd25519, sign.Algo)
+	assert.True(t, sign.IsCanonical())
+	assert.True(t, sign.Verify(publicKey, msg))
+	assert.False(t, sign.Verify(publicKey, []byte("no")))
+
+	other, err := GenerateKey(AlgoEd25519)
+	assert.Nil(t, err)
+	assert.False(t, sign.Verify(other.PublicKey(), msg))
+
+	recovered, err := RecoverPublicKey(msg, sign)
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey.Address(), recovered.Address())
+
+	_, err = RecoverPublicKey([]byte("no"), sign)
+	assert.NotNil(t, err)
+}
+
+func TestScheme_CrossAlgorithm(t *testing.T) {
+	edKey, err := GenerateKey(AlgoEd25519)
+	assert.Nil(t, err)
+	p256Key := GeneratePrivateKey()
+	msg := []byte("hello")
+
+	edSign, err := edKey.Sign(msg)
+	assert.Nil(t, err)
+	p256Sign, err := p256Key.Sign(msg)
+	assert.Nil(t, err)
+
+	assert.False(t, edSign.Verify(p256Key.PublicKey(), msg))
+	assert.False(t, p256Sign.Verify(edKey.PublicKey(), msg))
+	assert.NotEqual(t, edKey.PublicKey().Address(), p256Key.PublicKey().Address())
+}
+
+func TestScheme_Unknown(t *testing.T) {
+	_, err := LookupScheme(Algorithm(99))
+	assert.NotNil(t, err)
+	_, err = GenerateKey(Algorithm(99))
+	assert.NotNil(t, err)
+
+	_, err = RecoverPublicKey([]byte("hello"), &Signature{Algo: Algorithm(99)})
+	assert.NotNil(t, err)
+
+	algo, err := AlgorithmFromString("ed25519")
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoEd25519, algo)
+	_, err = AlgorithmFromString("rsa")
+	assert.NotNil(t, err)
+}
+
+func TestEd25519_Serialize(t *testing.T) {
+	privateKey, err := GenerateKey(AlgoEd25519)
+	assert.Nil(t, err)
+
+	dec, err := NewPrivateKey(AlgoEd25519, privateKey.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey.PublicKey().Address(), dec.PublicKey().Address())
+
+	data, err := privateKey.PEM()
+	assert.Nil(t, err)
+	dec, err = PrivateKeyFromPEM(data)
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoEd25519, dec.Algorithm())
+	assert.Equal(t, privateKey.Hex(), dec.Hex())
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, gob.NewEncoder(buf).Encode(privateKey.PublicKey()))
+	pub := PublicKey{}
+	assert.Nil(t, gob.NewDecoder(buf).Decode(&pub))
+	assert.Equal(t, AlgoEd25519, pub.Algo)
+	assert.Equal(t, privateKey.PublicKey().Address(), pub.Address())
+
+	encrypted, err := EncryptKey(privateKey, "secret", LightScryptN, LightScryptP)
+	assert.Nil(t, err)
+	dec, err = DecryptKey(encrypted, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoEd25519, dec.Algorithm())
+	assert.Equal(t, privateKey.Hex(), dec.Hex())
+}
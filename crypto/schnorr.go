@@ -0,0 +1,316 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Schnorr 签名与 MuSig 多重签名，基于 P-256 曲线。
+//
+// N 个签名者共同生成一个签名的流程：
+//  1. 每个签名者调用 NewSchnorrNonce 生成一次性随机数，先广播 Commitment，收齐后再广播 Public；
+//  2. 每个签名者用 VerifyNonceCommitment 检查其他人公布的随机数与承诺一致，再用 AggregateNonces 聚合；
+//  3. 每个签名者调用 SchnorrPartialSign 生成部分签名；
+//  4. 任意一方用 AggregateSchnorrSignatures 合并部分签名，得到的签名可用 AggregatePublicKeys 得到的聚合公钥验证。
+
+// SchnorrSignatureLength 是 Schnorr 签名序列化后的长度：压缩格式的 R 点加 32 字节的 s。
+const SchnorrSignatureLength = PublicKeyCompressedLength + 32
+
+var (
+	schnorrChallengeTag = []byte("MyChain/schnorr/challenge")
+	schnorrKeyAggTag    = []byte("MyChain/schnorr/keyagg")
+	schnorrNonceTag     = []byte("MyChain/schnorr/nonce")
+)
+
+// SchnorrSignature 是 Schnorr 签名 (R, s)，满足 s*G = R + e*X，其中 e = H(R || X || m)。
+type SchnorrSignature struct {
+	R []byte
+	S *big.Int
+}
+
+// Bytes 将签名序列化为 65 字节：33 字节压缩格式的 R 点和 32 字节大端序的 s。
+func (sig SchnorrSignature) Bytes() []byte {
+	b := make([]byte, SchnorrSignatureLength)
+	copy(b, sig.R)
+	sig.S.FillBytes(b[PublicKeyCompressedLength:])
+	return b
+}
+
+// SchnorrSignatureFromBytes 从 Bytes 的格式解析 Schnorr 签名。
+func SchnorrSignatureFromBytes(b []byte) (*SchnorrSignature, error) {
+	if len(b) != SchnorrSignatureLength {
+		return nil, fmt.Errorf("given bytes with length %d should be %d", len(b), SchnorrSignatureLength)
+	}
+	sig := &SchnorrSignature{
+		R: append([]byte{}, b[:PublicKeyCompressedLength]...),
+		S: new(big.Int).SetBytes(b[PublicKeyCompressedLength:]),
+	}
+	if sig.S.Cmp(curveOrder) >= 0 {
+		return nil, fmt.Errorf("invalid schnorr signature: s out of range")
+	}
+	return sig, nil
+}
+
+// SchnorrNonce 是签名者在一次多重签名中使用的一次性随机数，使用后即失效。
+type SchnorrNonce struct {
+	k      *big.Int
+	public []byte
+}
+
+// NewSchnorrNonce 生成一个一次性随机数 k 及其公开值 R = k*G。
+func NewSchnorrNonce() (*SchnorrNonce, error) {
+	k, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	x, y := elliptic.P256().ScalarBaseMult(k.FillBytes(make([]byte, 32)))
+	return &SchnorrNonce{k: k, public: elliptic.MarshalCompressed(elliptic.P256(), x, y)}, nil
+}
+
+// Public 返回随机数的公开值 R，压缩格式。
+func (n *SchnorrNonce) Public() []byte {
+	return append([]byte{}, n.public...)
+}
+
+// Commitment 返回随机数公开值的承诺 H(R)，签名者需在看到其他人的 R 之前先交换承诺。
+func (n *SchnorrNonce) Commitment() []byte {
+	return taggedHash(schnorrNonceTag, n.public)
+}
+
+// VerifyNonceCommitment 检查公布的随机数公开值是否与之前的承诺一致。
+func VerifyNonceCommitment(public, commitment []byte) bool {
+	return bytes.Equal(taggedHash(schnorrNonceTag, public), commitment)
+}
+
+// AggregateNonces 将所有签名者的随机数公开值相加，得到聚合随机数 R。
+func AggregateNonces(publics [][]byte) ([]byte, error) {
+	if len(publics) == 0 {
+		return nil, fmt.Errorf("no nonces to aggregate")
+	}
+	curve := elliptic.P256()
+	x, y := new(big.Int), new(big.Int)
+	for _, public := range publics {
+		px, py := elliptic.UnmarshalCompressed(curve, public)
+		if px == nil {
+			return nil, fmt.Errorf("invalid nonce: point is not on curve")
+		}
+		x, y = curve.Add(x, y, px, py)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, fmt.Errorf("aggregated nonce is point at infinity")
+	}
+	return elliptic.MarshalCompressed(curve, x, y), nil
+}
+
+// AggregatePublicKeys 按照 MuSig 聚合一组 P-256 公钥：X = sum(a_i * X_i)，a_i = H(L || X_i)。
+// L 是排序后所有公钥的哈希，系数 a_i 防止恶意签名者通过构造公钥抵消他人公钥的 rogue-key 攻击。
+// 聚合结果与公钥的顺序无关。
+//
+// 参数:
+//
+//	keys - 参与签名的公钥，不能重复。
+//
+// 返回值:
+//
+//	PublicKey - 聚合公钥。
+//	error - 公钥为空、重复或不是 P-256 公钥时返回错误。
+func AggregatePublicKeys(keys []PublicKey) (PublicKey, error) {
+	sorted, l, err := keyAggList(keys)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	curve := elliptic.P256()
+	x, y := new(big.Int), new(big.Int)
+	for _, key := range sorted {
+		a := keyAggCoefficient(l, key)
+		px, py := curve.ScalarMult(key.Key.X, key.Key.Y, a.FillBytes(make([]byte, 32)))
+		x, y = curve.Add(x, y, px, py)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return PublicKey{}, fmt.Errorf("aggregated key is point at infinity")
+	}
+	return PublicKey{Key: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+}
+
+// SchnorrPartialSign 生成 MuSig 部分签名 s_i = k_i + e * a_i * x_i。
+// 随机数在调用后即被销毁，同一随机数不能用于第二次签名。
+//
+// 参数:
+//
+//	key - 签名者私钥，必须是 P-256 私钥且其公钥在 keys 中。
+//	nonce - 签名者本次使用的随机数。
+//	keys - 所有签名者的公钥。
+//	aggNonce - AggregateNonces 得到的聚合随机数。
+//	msg - 待签名的消息。
+//
+// 返回值:
+//
+//	*big.Int - 部分签名。
+//	error - 参数不合法或随机数已被使用时返回错误。
+func SchnorrPartialSign(key PrivateKey, nonce *SchnorrNonce, keys []PublicKey, aggNonce []byte, msg []byte) (*big.Int, error) {
+	if key.algo != AlgoP256 {
+		return nil, fmt.Errorf("schnorr signatures require a p256 key")
+	}
+	if nonce == nil || nonce.k == nil {
+		return nil, fmt.Errorf("nonce has already been used")
+	}
+	_, l, err := keyAggList(keys)
+	if err != nil {
+		return nil, err
+	}
+	self := key.PublicKey()
+	found := false
+	for _, k := range keys {
+		if bytes.Equal(k.ToSlice(), self.ToSlice()) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("signer is not in the key set")
+	}
+	aggKey, err := AggregatePublicKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	e := schnorrChallenge(aggNonce, aggKey, msg)
+	a := keyAggCoefficient(l, self)
+	s := new(big.Int).Mul(e, a)
+	s.Mul(s, key.key.D)
+	s.Add(s, nonce.k).Mod(s, curveOrder)
+
+	// 销毁随机数，防止重复使用导致私钥泄露
+	nonce.k = nil
+	return s, nil
+}
+
+// AggregateSchnorrSignatures 合并部分签名，得到聚合签名 (R, sum(s_i))。
+func AggregateSchnorrSignatures(aggNonce []byte, partials []*big.Int) (*SchnorrSignature, error) {
+	if len(partials) == 0 {
+		return nil, fmt.Errorf("no partial signatures to aggregate")
+	}
+	if len(aggNonce) != PublicKeyCompressedLength {
+		return nil, fmt.Errorf("invalid aggregated nonce length %d", len(aggNonce))
+	}
+	s := new(big.Int)
+	for _, partial := range partials {
+		if partial == nil || partial.Sign() < 0 || partial.Cmp(curveOrder) >= 0 {
+			return nil, fmt.Errorf("invalid partial signature")
+		}
+		s.Add(s, partial)
+	}
+	s.Mod(s, curveOrder)
+	return &SchnorrSignature{R: append([]byte{}, aggNonce...), S: s}, nil
+}
+
+// SchnorrSign 使用单个私钥生成 Schnorr 签名，等价于只有一个签名者的 MuSig。
+func SchnorrSign(key PrivateKey, msg []byte) (*SchnorrSignature, error) {
+	nonce, err := NewSchnorrNonce()
+	if err != nil {
+		return nil, err
+	}
+	keys := []PublicKey{key.PublicKey()}
+	partial, err := SchnorrPartialSign(key, nonce, keys, nonce.public, msg)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateSchnorrSignatures(nonce.public, []*big.Int{partial})
+}
+
+// VerifySchnorr 使用（聚合）公钥验证 Schnorr 签名，检查 s*G = R + e*X。
+//
+// 参数:
+//
+//	pubKey - 签名者公钥，多重签名时为 AggregatePublicKeys 得到的聚合公钥。
+//	msg - 被签名的消息。
+//	sig - Schnorr 签名。
+//
+// 返回值:
+//
+//	返回一个布尔值，表示签名是否有效。
+func VerifySchnorr(pubKey PublicKey, msg []byte, sig *SchnorrSignature) bool {
+	if sig == nil || sig.S == nil || pubKey.Algo != AlgoP256 || pubKey.Key == nil {
+		return false
+	}
+	if sig.S.Sign() <= 0 || sig.S.Cmp(curveOrder) >= 0 {
+		return false
+	}
+	curve := elliptic.P256()
+	rx, ry := elliptic.UnmarshalCompressed(curve, sig.R)
+	if rx == nil {
+		return false
+	}
+	e := schnorrChallenge(sig.R, pubKey, msg)
+
+	lx, ly := curve.ScalarBaseMult(sig.S.FillBytes(make([]byte, 32)))
+	ex, ey := curve.ScalarMult(pubKey.Key.X, pubKey.Key.Y, e.FillBytes(make([]byte, 32)))
+	x, y := curve.Add(rx, ry, ex, ey)
+	return lx.Cmp(x) == 0 && ly.Cmp(y) == 0
+}
+
+// keyAggList 校验公钥集合并按压缩格式排序，返回排序后的公钥和 L = H(X_1 || ... || X_n)。
+func keyAggList(keys []PublicKey) ([]PublicKey, []byte, error) {
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("no public keys to aggregate")
+	}
+	sorted := make([]PublicKey, len(keys))
+	copy(sorted, keys)
+	for _, key := range sorted {
+		if key.Algo != AlgoP256 || key.Key == nil {
+			return nil, nil, fmt.Errorf("schnorr signatures require p256 public keys")
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].ToSlice(), sorted[j].ToSlice()) < 0
+	})
+	buf := make([]byte, 0, len(sorted)*PublicKeyCompressedLength)
+	for i, key := range sorted {
+		if i > 0 && bytes.Equal(key.ToSlice(), sorted[i-1].ToSlice()) {
+			return nil, nil, fmt.Errorf("duplicate public key %s", key.Hex())
+		}
+		buf = append(buf, key.ToSlice()...)
+	}
+	return sorted, taggedHash(schnorrKeyAggTag, buf), nil
+}
+
+func keyAggCoefficient(l []byte, key PublicKey) *big.Int {
+	a := new(big.Int).SetBytes(taggedHash(schnorrKeyAggTag, l, key.ToSlice()))
+	return a.Mod(a, curveOrder)
+}
+
+func schnorrChallenge(r []byte, pubKey PublicKey, msg []byte) *big.Int {
+	e := new(big.Int).SetBytes(taggedHash(schnorrChallengeTag, r, pubKey.ToSlice(), msg))
+	return e.Mod(e, curveOrder)
+}
+
+// taggedHash 计算带域分隔标签的 SHA-256：H(H(tag) || H(tag) || data...)。
+func taggedHash(tag []byte, data ...[]byte) []byte {
+	tagHash := sha256.Sum256(tag)
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// randomScalar 生成 [1, N-1] 范围内的随机标量。
+func randomScalar() (*big.Int, error) {
+	b := make([]byte, 32)
+	for {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		if validScalar(b) {
+			return new(big.Int).SetBytes(b), nil
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+// muSign 模拟所有签名者完成一次多重签名。
+func muSign(t *testing.T, keys []PrivateKey, msg []byte) (*SchnorrSignature, []PublicKey) {
+	pubKeys := make([]PublicKey, len(keys))
+	nonces := make([]*SchnorrNonce, len(keys))
+	commitments := make([][]byte, len(keys))
+	publics := make([][]byte, len(keys))
+	for i, key := range keys {
+		pubKeys[i] = key.PublicKey()
+		nonce, err := NewSchnorrNonce()
+		assert.Nil(t, err)
+		nonces[i] = nonce
+		commitments[i] = nonce.Commitment()
+	}
+	for i, nonce := range nonces {
+		publics[i] = nonce.Public()
+		assert.True(t, VerifyNonceCommitment(publics[i], commitments[i]))
+	}
+	aggNonce, err := AggregateNonces(publics)
+	assert.Nil(t, err)
+
+	partials := make([]*big.Int, len(keys))
+	for i, key := range keys {
+		partial, err := SchnorrPartialSign(key, nonces[i], pubKeys, aggNonce, msg)
+		assert.Nil(t, err)
+		partials[i] = partial
+	}
+	sig, err := AggregateSchnorrSignatures(aggNonce, partials)
+	assert.Nil(t, err)
+	return sig, pubKeys
+}
+
+func TestSchnorr_Sign_Verify(t *testing.T) {
+	privateKey := GeneratePrivateKey()
+	msg := []byte("hello")
+	sig, err := SchnorrSign(privateKey, msg)
+	assert.Nil(t, err)
+
+	aggKey, err := AggregatePublicKeys([]PublicKey{privateKey.PublicKey()})
+	assert.Nil(t, err)
+	assert.True(t, VerifySchnorr(aggKey, msg, sig))
+	assert.False(t, VerifySchnorr(aggKey, []byte("no"), sig))
+
+	dec, err := SchnorrSignatureFromBytes(sig.Bytes())
+	assert.Nil(t, err)
+	assert.True(t, VerifySchnorr(aggKey, msg, dec))
+}
+
+func TestSchnorr_MultiSig(t *testing.T) {
+	keys := make([]PrivateKey, 5)
+	for i := range keys {
+		keys[i] = GeneratePrivateKey()
+	}
+	msg := []byte("block header")
+	sig, pubKeys := muSign(t, keys, msg)
+
+	aggKey, err := AggregatePublicKeys(pubKeys)
+	assert.Nil(t, err)
+	assert.True(t, VerifySchnorr(aggKey, msg, sig))
+
+	// 聚合公钥与顺序无关
+	reversed := []PublicKey{pubKeys[4], pubKeys[3], pubKeys[2], pubKeys[1], pubKeys[0]}
+	aggKey2, err := AggregatePublicKeys(reversed)
+	assert.Nil(t, err)
+	assert.Equal(t, aggKey.Address(), aggKey2.Address())
+
+	// 缺少任意一个签名者的聚合公钥无法验证
+	partialKey, err := AggregatePublicKeys(pubKeys[:4])
+	assert.Nil(t, err)
+	assert.False(t, VerifySchnorr(partialKey, msg, sig))
+	assert.False(t, VerifySchnorr(aggKey, []byte("other"), sig))
+
+	// 篡改 s
+	tampered := &SchnorrSignature{R: sig.R, S: new(big.Int).Add(sig.S, big.NewInt(1))}
+	assert.False(t, VerifySchnorr(aggKey, msg, tampered))
+}
+
+func TestSchnorr_InvalidUsage(t *testing.T) {
+	key := GeneratePrivateKey()
+	other := GeneratePrivateKey()
+	keys := []PublicKey{key.PublicKey(), other.PublicKey()}
+
+	nonce, err := NewSchnorrNonce()
+	assert.Nil(t, err)
+	_, err = SchnorrPartialSign(key, nonce, keys, nonce.Public(), []byte("msg"))
+	assert.Nil(t, err)
+	// 随机数不能重复使用
+	_, err = SchnorrPartialSign(key, nonce, keys, nonce.Public(), []byte("msg"))
+	assert.NotNil(t, err)
+
+	// 签名者必须在公钥集合中
+	nonce, _ = NewSchnorrNonce()
+	outsider := GeneratePrivateKey()
+	_, err = SchnorrPartialSign(outsider, nonce, keys, nonce.Public(), []byte("msg"))
+	assert.NotNil(t, err)
+
+	_, err = AggregatePublicKeys([]PublicKey{key.PublicKey(), key.PublicKey()})
+	assert.NotNil(t, err)
+	_, err = AggregatePublicKeys(nil)
+	assert.NotNil(t, err)
+
+	edKey, _ := GenerateKey(AlgoEd25519)
+	_, err = AggregatePublicKeys([]PublicKey{edKey.PublicKey()})
+	assert.NotNil(t, err)
+	_, err = SchnorrSign(edKey, []byte("msg"))
+	assert.NotNil(t, err)
+
+	assert.False(t, VerifyNonceCommitment(nonce.Public(), []byte("bad")))
+	_, err = SchnorrSignatureFromBytes(make([]byte, 10))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,197 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// PrivateKeyLength 是私钥标量的原始字节长度。
+	PrivateKeyLength = 32
+	// PublicKeyCompressedLength 是 SEC1 压缩格式公钥的字节长度。
+	PublicKeyCompressedLength = 33
+	// PublicKeyUncompressedLength 是 SEC1 非压缩格式公钥的字节长度。
+	PublicKeyUncompressedLength = 65
+
+	pemPrivateKeyType = "PRIVATE KEY"
+)
+
+// Bytes 返回私钥的 32 字节原始表示。
+// P-256 私钥为标量 D 的大端序表示，Ed25519 私钥为其种子。
+func (k PrivateKey) Bytes() []byte {
+	if k.algo == AlgoEd25519 {
+		return k.edKey.Seed()
+	}
+	b := make([]byte, PrivateKeyLength)
+	k.key.D.FillBytes(b)
+	return b
+}
+
+// Hex 返回私钥标量的十六进制字符串。
+func (k PrivateKey) Hex() string {
+	return hex.EncodeToString(k.Bytes())
+}
+
+// PrivateKeyFromBytes 从 32 字节的原始标量解析 P-256 私钥。
+// 其他算法的私钥使用 NewPrivateKey 解析。
+//
+// 参数:
+//
+//	b - 大端序的私钥标量。
+//
+// 返回值:
+//
+//	PrivateKey - 解析得到的私钥。
+//	error - 长度不正确或标量不在 [1, N-1] 范围内时返回错误。
+func PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	if len(b) != PrivateKeyLength {
+		return PrivateKey{}, fmt.Errorf("given bytes with length %d should be %d", len(b), PrivateKeyLength)
+	}
+	// ecdh 会校验标量范围并计算对应的公钥点
+	priv, err := ecdh.P256().NewPrivateKey(b)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid private key: %w", err)
+	}
+	pub := priv.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(b),
+	}
+	return PrivateKey{algo: AlgoP256, key: key}, nil
+}
+
+// PrivateKeyFromHex 从十六进制字符串解析 P-256 私钥。
+func PrivateKeyFromHex(s string) (PrivateKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid private key hex: %w", err)
+	}
+	return PrivateKeyFromBytes(b)
+}
+
+// PEM 将私钥编码为 PKCS#8 格式的 PEM 数据。
+func (k PrivateKey) PEM() ([]byte, error) {
+	var key any = k.key
+	if k.algo == AlgoEd25519 {
+		key = k.edKey
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPrivateKeyType, Bytes: der}), nil
+}
+
+// PrivateKeyFromPEM 解析 PKCS#8 格式的 PEM 私钥。
+//
+// 参数:
+//
+//	data - PEM 编码的数据。
+//
+// 返回值:
+//
+//	PrivateKey - 解析得到的私钥。
+//	error - PEM 格式错误、密钥类型不是 P-256 ECDSA 或 Ed25519 时返回错误。
+func PrivateKeyFromPEM(data []byte) (PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return PrivateKey{}, fmt.Errorf("no PEM data found")
+	}
+	if block.Type != pemPrivateKeyType {
+		return PrivateKey{}, fmt.Errorf("unexpected PEM block type %q", block.Type)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+	switch key := parsed.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return PrivateKey{}, fmt.Errorf("unexpected curve %s", key.Curve.Params().Name)
+		}
+		return PrivateKey{algo: AlgoP256, key: key}, nil
+	case ed25519.PrivateKey:
+		return PrivateKey{algo: AlgoEd25519, edKey: key}, nil
+	default:
+		return PrivateKey{}, fmt.Errorf("unexpected private key type %T", parsed)
+	}
+}
+
+// Hex 返回 ToSlice 格式公钥的十六进制字符串。
+func (k PublicKey) Hex() string {
+	return hex.EncodeToString(k.ToSlice())
+}
+
+// PublicKeyFromBytes 从 SEC1 编码解析 P-256 公钥，同时支持压缩（33 字节）和非压缩（65 字节）格式。
+// 其他算法的公钥使用 NewPublicKey 解析。
+//
+// 参数:
+//
+//	b - SEC1 编码的公钥。
+//
+// 返回值:
+//
+//	PublicKey - 解析得到的公钥。
+//	error - 长度不正确或点不在 P-256 曲线上时返回错误。
+func PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	var x, y *big.Int
+	switch len(b) {
+	case PublicKeyCompressedLength:
+		// UnmarshalCompressed 会校验点是否在曲线上
+		x, y = elliptic.UnmarshalCompressed(elliptic.P256(), b)
+		if x == nil {
+			return PublicKey{}, fmt.Errorf("invalid public key: point is not on curve")
+		}
+	case PublicKeyUncompressedLength:
+		if _, err := ecdh.P256().NewPublicKey(b); err != nil {
+			return PublicKey{}, fmt.Errorf("invalid public key: %w", err)
+		}
+		x, y = new(big.Int).SetBytes(b[1:33]), new(big.Int).SetBytes(b[33:])
+	default:
+		return PublicKey{}, fmt.Errorf("given bytes with length %d should be %d or %d",
+			len(b), PublicKeyCompressedLength, PublicKeyUncompressedLength)
+	}
+	return PublicKey{Key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}, nil
+}
+
+// PublicKeyFromHex 从十六进制字符串解析 SEC1 编码的 P-256 公钥。
+func PublicKeyFromHex(s string) (PublicKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key hex: %w", err)
+	}
+	return PublicKeyFromBytes(b)
+}
+
+// GobEncode 将公钥编码为算法标识加 ToSlice 格式，避免 gob 直接序列化椭圆曲线对象。
+func (k PublicKey) GobEncode() ([]byte, error) {
+	if k.Key == nil && len(k.Ed25519) == 0 {
+		return []byte{}, nil
+	}
+	return append([]byte{byte(k.Algo)}, k.ToSlice()...), nil
+}
+
+// GobDecode 从 GobEncode 的格式解码公钥。
+func (k *PublicKey) GobDecode(b []byte) error {
+	if len(b) == 0 {
+		*k = PublicKey{}
+		return nil
+	}
+	pub, err := NewPublicKey(Algorithm(b[0]), b[1:])
+	if err != nil {
+		return err
+	}
+	*k = pub
+	return nil
+}
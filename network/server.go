@@ -18,6 +18,8 @@ type ServerOpts struct {
 	Transports    []Transport
 	BlockTime     time.Duration
 	PrivateKey    *crypto.PrivateKey
+	// Blockchain 是节点维护的区块链，为空时节点不处理区块，交易池的基础费用保持为 0
+	Blockchain *core.Blockchain
 }
 
 // Server represents a server that listens for incoming connections and handles them.
@@ -46,6 +48,9 @@ func NewServer(opts ServerOpts) *Server {
 	if s.RPCProcessor == nil {
 		s.RPCProcessor = s
 	}
+	if s.Blockchain != nil {
+		s.memPool.SetBaseFee(s.Blockchain.NextBaseFee())
+	}
 	if s.isValidator {
 		s.validateLoop()
 	}
@@ -65,6 +70,9 @@ func (s *Server) ProcessMessage(message *DecodeMessage) error {
 	case *core.Transaction:
 		// 处理交易消息
 		return s.processTransaction(t)
+	case *core.Block:
+		// 处理区块消息
+		return s.processBlock(t)
 	}
 
 	// 如果消息类型不匹配任何已知类型，则不处理，返回 nil
@@ -176,6 +184,28 @@ func (s *Server) validateLoop() {
 	}
 }
 
+// processBlock 将区块添加到区块链，并将交易池的基础费用更新为下一个区块的基础费用，
+// 使交易池按下一个区块能够接受的价格接收和选择交易。
+// 参数:
+//
+//	b *core.Block: 需要添加的区块。
+//
+// 返回值:
+//
+//	error: 节点没有区块链或区块无效时返回错误；否则返回nil。
+func (s *Server) processBlock(b *core.Block) error {
+	if s.Blockchain == nil {
+		return fmt.Errorf("server has no blockchain to add block %d", b.Height)
+	}
+	if err := s.Blockchain.AddBlock(b); err != nil {
+		return err
+	}
+	s.memPool.SetBaseFee(s.Blockchain.NextBaseFee())
+	logrus.WithFields(logrus.Fields{"height": b.Height, "baseFee": s.memPool.BaseFee()}).Infoln("Update memPool base fee")
+	return nil
+}
+
+// createNewBlock 尚未实现出块，产生的区块需要通过 processBlock 添加，以便更新交易池的基础费用。
 func (s *Server) createNewBlock() error {
 	fmt.Printf("creating a new block")
 	return nil
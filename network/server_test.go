@@ -0,0 +1,74 @@
+package network
+
+import (
+	"MyChain/core"
+	"MyChain/crypto"
+	"MyChain/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// nextBlock 创建接在链尾、由 validator 签名的区块，并填写区块头中由执行结果决定的字段。
+func nextBlock(t *testing.T, bc *core.Blockchain, validator crypto.PrivateKey, txs ...*core.Transaction) *core.Block {
+	prev, err := bc.GetHeader(bc.Height())
+	assert.Nil(t, err)
+	b := core.NewBlock(&core.Header{
+		PrevBlockHash: core.BlockHasher{}.Hash(prev),
+		Timestamp:     time.Now().UnixNano(),
+		Height:        bc.Height() + 1,
+		BaseFee:       bc.NextBaseFee(),
+	}, nil)
+	for _, tx := range txs {
+		b.AddTransaction(tx)
+	}
+	b.Validator = validator.PublicKey()
+	result, err := bc.ExecuteBlock(b)
+	assert.Nil(t, err)
+	b.StateRoot = result.State.Root()
+	b.ReceiptsRoot = core.ReceiptsRoot(result.Receipts)
+	b.LogsBloom = core.CreateBloom(result.Receipts)
+	b.GasUsed = result.GasUsed
+	assert.Nil(t, b.Sign(validator))
+	return b
+}
+
+func TestServer_ProcessBlock_BaseFee(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	state := core.NewState()
+	assert.Nil(t, state.AddBalance(alice.PublicKey().Address(), 1000000000))
+	bc, err := core.NewBlockChainWithState(core.NewBlock(&core.Header{BaseFee: 64}, nil), state)
+	assert.Nil(t, err)
+	// 目标用量为一笔转账的一半，区块中有一笔转账时基础费用上涨 1/8
+	bc.SetFeeMarket(core.FeeMarket{TargetUsage: core.TxGas / 2, ChangeDenominator: 8})
+
+	// 创世区块为空，下一个区块的基础费用下降 1/8
+	s := NewServer(ServerOpts{Blockchain: bc})
+	assert.Equal(t, uint64(56), s.memPool.BaseFee())
+
+	tx := core.NewTransferTransaction(types.Address{1}, 1)
+	tx.GasPrice = 56
+	assert.Nil(t, tx.Sign(alice))
+	b := nextBlock(t, bc, crypto.GeneratePrivateKey(), tx)
+	assert.Nil(t, s.ProcessMessage(&DecodeMessage{Data: b}))
+	assert.Equal(t, uint32(1), bc.Height())
+	assert.Equal(t, uint64(63), bc.NextBaseFee())
+	assert.Equal(t, bc.NextBaseFee(), s.memPool.BaseFee())
+
+	// 交易池按新的基础费用接收交易
+	low := core.NewTransferTransaction(types.Address{1}, 1)
+	low.Nonce = 1
+	low.GasPrice = 56
+	assert.Nil(t, low.Sign(alice))
+	assert.NotNil(t, s.memPool.Add(low))
+
+	// 无效的区块不改变基础费用
+	assert.NotNil(t, s.ProcessMessage(&DecodeMessage{Data: b}))
+	assert.Equal(t, uint64(63), s.memPool.BaseFee())
+}
+
+func TestServer_ProcessBlock_NoBlockchain(t *testing.T) {
+	s := NewServer(ServerOpts{})
+	assert.Equal(t, uint64(0), s.memPool.BaseFee())
+	assert.NotNil(t, s.ProcessMessage(&DecodeMessage{Data: core.NewBlock(&core.Header{Height: 1}, nil)}))
+}
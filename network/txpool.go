@@ -3,6 +3,7 @@ package network
 import (
 	"MyChain/core"
 	"MyChain/types"
+	"fmt"
 	"sort"
 )
 
@@ -14,7 +15,11 @@ func (s *TxMapSorter) Len() int {
 	return len(s.transactions)
 }
 
+// Less 按 gas 价格从高到低排序，价格相同时先收到的交易排在前面。
 func (s *TxMapSorter) Less(i, j int) bool {
+	if s.transactions[i].GasPrice != s.transactions[j].GasPrice {
+		return s.transactions[i].GasPrice > s.transactions[j].GasPrice
+	}
 	return s.transactions[i].FirstSeen() < s.transactions[j].FirstSeen()
 }
 
@@ -33,11 +38,38 @@ func NewTxMapSorter(txMap map[types.Hash]*core.Transaction) *TxMapSorter {
 		transactions: txs,
 	}
 	sort.Sort(s)
+	s.orderByNonce()
 	return s
 }
 
+// orderByNonce 保持同一发送者的交易按 nonce 递增排列，否则 gas 价格更高的后续交易会排在前面而无法被打包。
+// 每个发送者的交易仍占据按 gas 价格排序后的位置，只是在这些位置之间按 nonce 重新分配。
+func (s *TxMapSorter) orderByNonce() {
+	positions := make(map[types.Address][]int)
+	for i, tx := range s.transactions {
+		if from := tx.From(); from != (types.Address{}) {
+			positions[from] = append(positions[from], i)
+		}
+	}
+	for _, idx := range positions {
+		if len(idx) < 2 {
+			continue
+		}
+		txs := make([]*core.Transaction, len(idx))
+		for k, i := range idx {
+			txs[k] = s.transactions[i]
+		}
+		sort.SliceStable(txs, func(a, b int) bool { return txs[a].Nonce < txs[b].Nonce })
+		for k, i := range idx {
+			s.transactions[i] = txs[k]
+		}
+	}
+}
+
 type TxPool struct {
 	transactions map[types.Hash]*core.Transaction
+	// baseFee 是下一个区块的基础费用，gas 价格低于该值的交易无法被打包
+	baseFee uint64
 }
 
 func NewTxPool() *TxPool {
@@ -46,9 +78,40 @@ func NewTxPool() *TxPool {
 	}
 }
 
+// Transactions 返回交易池中可以被打包的交易，按打包优先级排序。
+// 同一发送者的交易按 nonce 递增，遇到第一笔 gas 价格低于当前基础费用的交易后，该发送者之后的交易都不返回，
+// 因为缺少前一个 nonce 的交易无法被打包。
 func (p *TxPool) Transactions() []*core.Transaction {
 	s := NewTxMapSorter(p.transactions)
-	return s.transactions
+	txs := s.transactions[:0]
+	blocked := make(map[types.Address]bool)
+	for _, tx := range s.transactions {
+		from := tx.From()
+		if blocked[from] {
+			continue
+		}
+		if tx.GasPrice < p.baseFee {
+			// 未验证的交易没有发送者，只跳过该交易本身
+			if from != (types.Address{}) {
+				blocked[from] = true
+			}
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// SetBaseFee 设置下一个区块的基础费用。已在池中但 gas 价格低于新基础费用的交易会被保留，
+// 但在基础费用回落之前不会被 Transactions 返回。
+// Server 在创建时和每个区块被添加后以 Blockchain.NextBaseFee 的结果调用该方法。
+func (p *TxPool) SetBaseFee(baseFee uint64) {
+	p.baseFee = baseFee
+}
+
+// BaseFee 返回交易池当前使用的基础费用。
+func (p *TxPool) BaseFee() uint64 {
+	return p.baseFee
 }
 
 func (p *TxPool) Len() int {
@@ -73,7 +136,7 @@ func (p *TxPool) Has(hash types.Hash) bool {
 	return ok
 }
 
-// Add 将一个交易添加到交易池中。
+// Add 将一个交易添加到交易池中。不符合交易种类要求、GasLimit 低于固定消耗或 gas 价格低于当前基础费用的交易会被拒绝。
 // 如果该交易已经存在于交易池中，调用方需要确保交易没有已经存在于交易池中
 // 参数：
 //
@@ -83,8 +146,18 @@ func (p *TxPool) Has(hash types.Hash) bool {
 //
 //	error: 如果添加过程中遇到错误，则返回错误信息；否则返回nil。
 func (p *TxPool) Add(tx *core.Transaction) error {
+	// 先校验交易，格式错误的签名不参与哈希计算
+	if err := tx.Validate(); err != nil {
+		return fmt.Errorf("invalid transaction: %w", err)
+	}
 	// 生成交易的哈希值
 	hash := tx.Hash(core.TxHasher{})
+	if gas := core.IntrinsicGas(tx); tx.GasLimit < gas {
+		return fmt.Errorf("transaction %s gas limit %d is below intrinsic gas %d", hash, tx.GasLimit, gas)
+	}
+	if tx.GasPrice < p.baseFee {
+		return fmt.Errorf("transaction %s gas price %d is below base fee %d", hash, tx.GasPrice, p.baseFee)
+	}
 	// 将新交易添加到交易池
 	p.transactions[hash] = tx
 	return nil
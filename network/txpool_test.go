@@ -2,7 +2,10 @@ package network
 
 import (
 	"MyChain/core"
+	"MyChain/crypto"
+	"MyChain/types"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"math/rand"
 	"strconv"
 	"testing"
@@ -41,3 +44,109 @@ func TestNewTxMapSorter(t *testing.T) {
 		assert.True(t, transactions[i].FirstSeen() < transactions[i+1].FirstSeen())
 	}
 }
+
+func TestNewTxMapSorter_Fee(t *testing.T) {
+	p := NewTxPool()
+	fees := []uint64{5, 1, 9, 5}
+	for i, fee := range fees {
+		tx := core.NewTransaction([]byte(strconv.Itoa(i)))
+		tx.GasPrice = fee
+		tx.SetFirstSeen(int64(i))
+		assert.Nil(t, p.Add(tx))
+	}
+	transactions := p.Transactions()
+	assert.Equal(t, uint64(9), transactions[0].GasPrice)
+	assert.Equal(t, int64(0), transactions[1].FirstSeen())
+	assert.Equal(t, int64(3), transactions[2].FirstSeen())
+	assert.Equal(t, uint64(1), transactions[3].GasPrice)
+}
+
+func TestNewTxMapSorter_SenderNonce(t *testing.T) {
+	p := NewTxPool()
+	privateKey := crypto.GeneratePrivateKey()
+	// 同一发送者的后续交易手续费更高，也不能排在前一笔交易之前
+	for nonce, fee := range []uint64{1, 10} {
+		tx := core.NewTransferTransaction(types.MustAddressFromBytes(types.RandomBytes(20)), 1)
+		tx.Nonce = uint64(nonce)
+		tx.GasPrice = fee
+		assert.Nil(t, tx.Sign(privateKey))
+		assert.Nil(t, p.Add(tx))
+	}
+	other := core.NewTransaction([]byte("other"))
+	other.GasPrice = 5
+	assert.Nil(t, p.Add(other))
+
+	transactions := p.Transactions()
+	assert.Equal(t, uint64(0), transactions[0].Nonce)
+	assert.Equal(t, other, transactions[1])
+	assert.Equal(t, uint64(1), transactions[2].Nonce)
+}
+
+func TestTxPool_BaseFee(t *testing.T) {
+	p := NewTxPool()
+	p.SetBaseFee(10)
+
+	low := core.NewTransaction([]byte("low"))
+	low.GasPrice = 9
+	assert.NotNil(t, p.Add(low))
+	assert.Equal(t, 0, p.Len())
+
+	tx := core.NewTransaction([]byte("foo"))
+	tx.GasPrice = 10
+	assert.Nil(t, p.Add(tx))
+	assert.Equal(t, []*core.Transaction{tx}, p.Transactions())
+
+	// GasLimit 低于固定消耗
+	tx2 := core.NewTransaction([]byte("bar"))
+	tx2.GasPrice = 10
+	tx2.GasLimit--
+	assert.NotNil(t, p.Add(tx2))
+
+	// 不符合交易种类要求
+	tx3 := core.NewDeployTransaction(nil)
+	tx3.GasPrice = 10
+	assert.NotNil(t, p.Add(tx3))
+
+	// 基础费用上涨后交易仍在池中，但不会被打包
+	p.SetBaseFee(11)
+	assert.Equal(t, 1, p.Len())
+	assert.Empty(t, p.Transactions())
+}
+
+func TestTxPool_Add_MalformedSignature(t *testing.T) {
+	p := NewTxPool()
+	malformed := []func(tx *core.Transaction){
+		func(tx *core.Transaction) { tx.Signature = &crypto.Signature{S: big.NewInt(1)} },
+		func(tx *core.Transaction) { tx.Signatures = []*crypto.Signature{nil} },
+	}
+	for i, modify := range malformed {
+		tx := core.NewTransferTransaction(types.Address{1}, 1)
+		modify(tx)
+		assert.NotPanics(t, func() {
+			assert.NotNil(t, p.Add(tx), "transaction %d", i)
+		})
+	}
+	assert.Equal(t, 0, p.Len())
+}
+
+func TestTxPool_BaseFee_SenderNonce(t *testing.T) {
+	privateKey := crypto.GeneratePrivateKey()
+	other := crypto.GeneratePrivateKey()
+	p := NewTxPool()
+	add := func(key crypto.PrivateKey, nonce, gasPrice uint64) *core.Transaction {
+		tx := core.NewTransferTransaction(types.Address{1}, 1)
+		tx.Nonce = nonce
+		tx.GasPrice = gasPrice
+		assert.Nil(t, tx.Sign(key))
+		assert.Nil(t, p.Add(tx))
+		return tx
+	}
+	tx0 := add(privateKey, 0, 20)
+	add(privateKey, 1, 10)
+	add(privateKey, 2, 30)
+	tx3 := add(other, 0, 15)
+
+	// nonce 1 低于基础费用后，同一发送者 nonce 2 的交易即使价格更高也不能被打包，其他发送者不受影响
+	p.SetBaseFee(11)
+	assert.Equal(t, []*core.Transaction{tx0, tx3}, p.Transactions())
+}
@@ -3,10 +3,25 @@ package types
 import (
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 type Address [20]uint8
 
+const (
+	// MainNetAddressPrefix 和 TestNetAddressPrefix 是编码地址使用的网络前缀。
+	MainNetAddressPrefix = "mc"
+	TestNetAddressPrefix = "tmc"
+	// DefaultAddressPrefix 是 MarshalText 编码地址时使用的前缀。
+	// 需要使用其他网络前缀时调用 Encode，需要校验网络时调用 DecodeAddressWithPrefix。
+	DefaultAddressPrefix = MainNetAddressPrefix
+)
+
+// IsKnownAddressPrefix 判断前缀是否为已知网络的地址前缀。
+func IsKnownAddressPrefix(prefix string) bool {
+	return prefix == MainNetAddressPrefix || prefix == TestNetAddressPrefix
+}
+
 func (a Address) ToSlice() []byte {
 	b := make([]byte, 20)
 	for i := 0; i < 20; i++ {
@@ -15,18 +30,131 @@ func (a Address) ToSlice() []byte {
 	return b
 }
 
-func AddressFromBytes(b []byte) Address {
+// ParseAddress 从 20 字节解析地址，长度不正确时返回错误。
+func ParseAddress(b []byte) (Address, error) {
 	if len(b) != 20 {
-		msg := fmt.Sprintf("Given bytes with length %d should be 20", len(b))
-		panic(msg)
+		return Address{}, fmt.Errorf("given bytes with length %d should be 20", len(b))
 	}
 	var value [20]uint8
 	for i := 0; i < 20; i++ {
 		value[i] = b[i]
 	}
-	return Address(value)
+	return Address(value), nil
+}
+
+// ParseAddressHex 从十六进制字符串解析地址，允许带 0x 前缀。
+func ParseAddressHex(s string) (Address, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return Address{}, fmt.Errorf("invalid address hex: %w", err)
+	}
+	return ParseAddress(b)
+}
+
+// MustAddressFromBytes 与 ParseAddress 相同，但在长度不正确时 panic，仅用于长度已知正确的场景。
+func MustAddressFromBytes(b []byte) Address {
+	a, err := ParseAddress(b)
+	if err != nil {
+		panic(err)
+	}
+	return a
 }
 
 func (a Address) String() string {
 	return hex.EncodeToString(a.ToSlice())
 }
+
+// Encode 将地址编码为带网络前缀和校验和的 bech32 字符串，如 mc1...。
+//
+// 参数:
+//
+//	prefix - 网络前缀。
+//
+// 返回值:
+//
+//	string - 编码后的地址。
+//	error - 前缀不合法时返回错误。
+func (a Address) Encode(prefix string) (string, error) {
+	data, err := convertBits(a.ToSlice(), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(prefix, data)
+}
+
+// DecodeAddress 解析 bech32 编码的地址，返回其网络前缀和地址。
+//
+// 参数:
+//
+//	s - 编码后的地址。
+//
+// 返回值:
+//
+//	string - 地址的网络前缀。
+//	Address - 解析得到的地址。
+//	error - 格式错误、校验和错误或长度不正确时返回错误。
+func DecodeAddress(s string) (string, Address, error) {
+	prefix, data, err := bech32Decode(s)
+	if err != nil {
+		return "", Address{}, err
+	}
+	b, err := convertBits(data, 5, 8, false)
+	if err != nil {
+		return "", Address{}, err
+	}
+	a, err := ParseAddress(b)
+	if err != nil {
+		return "", Address{}, err
+	}
+	return prefix, a, nil
+}
+
+// DecodeAddressWithPrefix 解析 bech32 编码的地址，并要求其网络前缀与 prefix 一致。
+func DecodeAddressWithPrefix(s, prefix string) (Address, error) {
+	p, a, err := DecodeAddress(s)
+	if err != nil {
+		return Address{}, err
+	}
+	if p != prefix {
+		return Address{}, fmt.Errorf("invalid address prefix, expected %s, got %s", prefix, p)
+	}
+	return a, nil
+}
+
+// MarshalText 使用 DefaultAddressPrefix 将地址编码为 bech32 文本，实现 encoding.TextMarshaler。
+func (a Address) MarshalText() ([]byte, error) {
+	s, err := a.Encode(DefaultAddressPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText 解析 bech32 文本地址，实现 encoding.TextUnmarshaler。
+// 接受任一已知网络的前缀，解码结果不依赖进程状态；需要限定网络时使用 DecodeAddressWithPrefix。
+func (a *Address) UnmarshalText(text []byte) error {
+	prefix, decoded, err := DecodeAddress(string(text))
+	if err != nil {
+		return err
+	}
+	if !IsKnownAddressPrefix(prefix) {
+		return fmt.Errorf("unknown address prefix %s", prefix)
+	}
+	*a = decoded
+	return nil
+}
+
+// MarshalBinary 返回地址的原始 20 字节，使 gob 等二进制编码不受文本格式和网络前缀影响。
+func (a Address) MarshalBinary() ([]byte, error) {
+	return a.ToSlice(), nil
+}
+
+// UnmarshalBinary 从原始 20 字节解析地址。
+func (a *Address) UnmarshalBinary(b []byte) error {
+	decoded, err := ParseAddress(b)
+	if err != nil {
+		return err
+	}
+	*a = decoded
+	return nil
+}
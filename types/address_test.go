@@ -0,0 +1,138 @@
+package types
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func randomAddress() Address {
+	return MustAddressFromBytes(RandomBytes(20))
+}
+
+func TestBech32_Vectors(t *testing.T) {
+	// BIP-173 中的有效 bech32 字符串
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	}
+	for _, s := range valid {
+		hrp, data, err := bech32Decode(s)
+		assert.Nil(t, err, s)
+		encoded, err := bech32Encode(hrp, data)
+		assert.Nil(t, err, s)
+		assert.Equal(t, strings.ToLower(s), encoded)
+	}
+
+	invalid := []string{
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+		"a12UEL5L",
+	}
+	for _, s := range invalid {
+		_, _, err := bech32Decode(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestAddress_Encode_Decode(t *testing.T) {
+	a := randomAddress()
+	s, err := a.Encode(MainNetAddressPrefix)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(s, MainNetAddressPrefix+"1"))
+
+	prefix, dec, err := DecodeAddress(s)
+	assert.Nil(t, err)
+	assert.Equal(t, MainNetAddressPrefix, prefix)
+	assert.Equal(t, a, dec)
+
+	_, err = DecodeAddressWithPrefix(s, TestNetAddressPrefix)
+	assert.NotNil(t, err)
+}
+
+func TestAddress_Decode_Typo(t *testing.T) {
+	a := randomAddress()
+	s, err := a.Encode(MainNetAddressPrefix)
+	assert.Nil(t, err)
+
+	// 修改任意一个字符都会导致校验和错误
+	for i := len(MainNetAddressPrefix) + 1; i < len(s); i++ {
+		c := byte('q')
+		if s[i] == 'q' {
+			c = 'p'
+		}
+		typo := s[:i] + string(c) + s[i+1:]
+		_, _, err := DecodeAddress(typo)
+		assert.NotNil(t, err, typo)
+	}
+
+	_, _, err = DecodeAddress("not an address")
+	assert.NotNil(t, err)
+}
+
+func TestAddress_Text(t *testing.T) {
+	a := randomAddress()
+	b, err := json.Marshal(map[string]Address{"to": a})
+	assert.Nil(t, err)
+
+	s, _ := a.Encode(DefaultAddressPrefix)
+	assert.Contains(t, string(b), s)
+
+	dec := map[string]Address{}
+	assert.Nil(t, json.Unmarshal(b, &dec))
+	assert.Equal(t, a, dec["to"])
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"to":"mc1invalid"}`), &dec))
+
+	// 任一已知网络的前缀都可以解码，未知前缀被拒绝
+	testnet, err := a.Encode(TestNetAddressPrefix)
+	assert.Nil(t, err)
+	var decoded Address
+	assert.Nil(t, decoded.UnmarshalText([]byte(testnet)))
+	assert.Equal(t, a, decoded)
+	unknown, err := a.Encode("xyz")
+	assert.Nil(t, err)
+	assert.NotNil(t, decoded.UnmarshalText([]byte(unknown)))
+}
+
+func TestAddress_Gob(t *testing.T) {
+	a := randomAddress()
+	buf := &bytes.Buffer{}
+	assert.Nil(t, gob.NewEncoder(buf).Encode(a))
+
+	dec := Address{}
+	assert.Nil(t, gob.NewDecoder(buf).Decode(&dec))
+	assert.Equal(t, a, dec)
+}
+
+func TestParseAddress(t *testing.T) {
+	a := randomAddress()
+	dec, err := ParseAddress(a.ToSlice())
+	assert.Nil(t, err)
+	assert.Equal(t, a, dec)
+
+	dec, err = ParseAddressHex(a.String())
+	assert.Nil(t, err)
+	assert.Equal(t, a, dec)
+	dec, err = ParseAddressHex("0x" + a.String())
+	assert.Nil(t, err)
+	assert.Equal(t, a, dec)
+
+	_, err = ParseAddress(RandomBytes(19))
+	assert.NotNil(t, err)
+	_, err = ParseAddressHex("zz")
+	assert.NotNil(t, err)
+	assert.NotNil(t, new(Address).UnmarshalBinary(RandomBytes(21)))
+	assert.Panics(t, func() { MustAddressFromBytes(RandomBytes(21)) })
+}
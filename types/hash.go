@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 type Hash [32]uint8
@@ -27,16 +28,34 @@ func (h Hash) String() string {
 	return hex.EncodeToString(h.ToSlice())
 }
 
-func HashFromBytes(b []byte) Hash {
+// ParseHash 从 32 字节解析哈希，长度不正确时返回错误。
+func ParseHash(b []byte) (Hash, error) {
 	if len(b) != 32 {
-		msg := fmt.Sprintf("Given bytes with length %d should be 32", len(b))
-		panic(msg)
+		return Hash{}, fmt.Errorf("given bytes with length %d should be 32", len(b))
 	}
 	var value [32]uint8
 	for i := 0; i < 32; i++ {
 		value[i] = b[i]
 	}
-	return Hash(value)
+	return Hash(value), nil
+}
+
+// ParseHashHex 从十六进制字符串解析哈希，允许带 0x 前缀。
+func ParseHashHex(s string) (Hash, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return Hash{}, fmt.Errorf("invalid hash hex: %w", err)
+	}
+	return ParseHash(b)
+}
+
+// MustHashFromBytes 与 ParseHash 相同，但在长度不正确时 panic，仅用于长度已知正确的场景。
+func MustHashFromBytes(b []byte) Hash {
+	h, err := ParseHash(b)
+	if err != nil {
+		panic(err)
+	}
+	return h
 }
 
 func RandomBytes(size int) []byte {
@@ -45,5 +64,5 @@ func RandomBytes(size int) []byte {
 	return token
 }
 func RandomHash() Hash {
-	return HashFromBytes(RandomBytes(32))
+	return MustHashFromBytes(RandomBytes(32))
 }
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseHash(t *testing.T) {
+	h := RandomHash()
+	dec, err := ParseHash(h.ToSlice())
+	assert.Nil(t, err)
+	assert.Equal(t, h, dec)
+
+	dec, err = ParseHashHex(h.String())
+	assert.Nil(t, err)
+	assert.Equal(t, h, dec)
+
+	_, err = ParseHash(RandomBytes(31))
+	assert.NotNil(t, err)
+	_, err = ParseHashHex("0x1234")
+	assert.NotNil(t, err)
+	_, err = ParseHashHex("not hex")
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { MustHashFromBytes(RandomBytes(33)) })
+}
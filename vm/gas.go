@@ -0,0 +1,66 @@
+package vm
+
+// 指令的 gas 消耗，数值参考 EVM。
+const (
+	GasZero        uint64 = 0
+	GasJumpDest    uint64 = 1
+	GasQuick       uint64 = 2
+	GasFastest     uint64 = 3
+	GasFast        uint64 = 5
+	GasMid         uint64 = 8
+	GasSlow        uint64 = 10
+	GasSHA256      uint64 = 30
+	GasSHA256Word  uint64 = 6
+	GasMemoryWord  uint64 = 3
+	GasSLoad       uint64 = 200
+	GasSStoreSet   uint64 = 20000
+	GasSStoreReset uint64 = 5000
+	GasLog         uint64 = 375
+	GasLogTopic    uint64 = 375
+	GasLogData     uint64 = 8
+	// memoryQuadCoeffDiv 是内存扩展费用中平方项的除数
+	memoryQuadCoeffDiv uint64 = 512
+)
+
+// constantGas 返回指令固定的 gas 消耗，SHA256、SSTORE、LOG 和访问内存的指令还有额外的动态消耗。
+func constantGas(op OpCode) uint64 {
+	switch {
+	case op.IsPush(), op >= DUP1 && op <= DUP16, op >= SWAP1 && op <= SWAP16:
+		return GasFastest
+	case op.IsLog():
+		return GasLog + uint64(op.LogTopics())*GasLogTopic
+	}
+	switch op {
+	case STOP, RETURN, REVERT, SSTORE:
+		return GasZero
+	case JUMPDEST:
+		return GasJumpDest
+	case ADDRESS, CALLER, CALLVALUE, CALLDATASIZE, TIMESTAMP, NUMBER, POP, PC, MSIZE:
+		return GasQuick
+	case ADD, SUB, LT, GT, EQ, ISZERO, AND, OR, XOR, NOT, CALLDATALOAD, MLOAD, MSTORE, MSTORE8:
+		return GasFastest
+	case MUL, DIV, MOD:
+		return GasFast
+	case JUMP:
+		return GasMid
+	case JUMPI:
+		return GasSlow
+	case SHA256:
+		return GasSHA256
+	case SLOAD:
+		return GasSLoad
+	}
+	return GasZero
+}
+
+// memoryGas 返回容纳 size 字节的内存所需的总 gas，
+// 即每个字长 GasMemoryWord 加上字长数平方除以 memoryQuadCoeffDiv。
+func memoryGas(size uint64) uint64 {
+	words := toWords(size)
+	return words*GasMemoryWord + words*words/memoryQuadCoeffDiv
+}
+
+// toWords 返回容纳 size 字节所需的字长数。
+func toWords(size uint64) uint64 {
+	return (size + WordSize - 1) / WordSize
+}
@@ -0,0 +1,113 @@
+package vm
+
+import "fmt"
+
+// OpCode 是虚拟机的指令。指令编码参考 EVM，便于阅读和使用已有工具。
+type OpCode byte
+
+const (
+	STOP OpCode = 0x00
+	ADD  OpCode = 0x01
+	MUL  OpCode = 0x02
+	SUB  OpCode = 0x03
+	DIV  OpCode = 0x04
+	MOD  OpCode = 0x06
+
+	LT     OpCode = 0x10
+	GT     OpCode = 0x11
+	EQ     OpCode = 0x14
+	ISZERO OpCode = 0x15
+	AND    OpCode = 0x16
+	OR     OpCode = 0x17
+	XOR    OpCode = 0x18
+	NOT    OpCode = 0x19
+
+	SHA256 OpCode = 0x20
+
+	ADDRESS      OpCode = 0x30
+	CALLER       OpCode = 0x33
+	CALLVALUE    OpCode = 0x34
+	CALLDATALOAD OpCode = 0x35
+	CALLDATASIZE OpCode = 0x36
+
+	TIMESTAMP OpCode = 0x42
+	NUMBER    OpCode = 0x43
+
+	POP      OpCode = 0x50
+	MLOAD    OpCode = 0x51
+	MSTORE   OpCode = 0x52
+	MSTORE8  OpCode = 0x53
+	SLOAD    OpCode = 0x54
+	SSTORE   OpCode = 0x55
+	JUMP     OpCode = 0x56
+	JUMPI    OpCode = 0x57
+	PC       OpCode = 0x58
+	MSIZE    OpCode = 0x59
+	JUMPDEST OpCode = 0x5b
+
+	PUSH1  OpCode = 0x60
+	PUSH32 OpCode = 0x7f
+	DUP1   OpCode = 0x80
+	DUP16  OpCode = 0x8f
+	SWAP1  OpCode = 0x90
+	SWAP16 OpCode = 0x9f
+	LOG0   OpCode = 0xa0
+	LOG4   OpCode = 0xa4
+
+	RETURN OpCode = 0xf3
+	REVERT OpCode = 0xfd
+)
+
+var opCodeNames = map[OpCode]string{
+	STOP: "STOP", ADD: "ADD", MUL: "MUL", SUB: "SUB", DIV: "DIV", MOD: "MOD",
+	LT: "LT", GT: "GT", EQ: "EQ", ISZERO: "ISZERO", AND: "AND", OR: "OR", XOR: "XOR", NOT: "NOT",
+	SHA256:  "SHA256",
+	ADDRESS: "ADDRESS", CALLER: "CALLER", CALLVALUE: "CALLVALUE", CALLDATALOAD: "CALLDATALOAD", CALLDATASIZE: "CALLDATASIZE",
+	TIMESTAMP: "TIMESTAMP", NUMBER: "NUMBER",
+	POP: "POP", MLOAD: "MLOAD", MSTORE: "MSTORE", MSTORE8: "MSTORE8", SLOAD: "SLOAD", SSTORE: "SSTORE",
+	JUMP: "JUMP", JUMPI: "JUMPI", PC: "PC", MSIZE: "MSIZE", JUMPDEST: "JUMPDEST",
+	RETURN: "RETURN", REVERT: "REVERT",
+}
+
+// IsPush 判断指令是否为 PUSH1 到 PUSH32。
+func (op OpCode) IsPush() bool {
+	return op >= PUSH1 && op <= PUSH32
+}
+
+// PushSize 返回 PUSH 指令携带的立即数字节数，其他指令返回 0。
+func (op OpCode) PushSize() int {
+	if !op.IsPush() {
+		return 0
+	}
+	return int(op-PUSH1) + 1
+}
+
+// IsLog 判断指令是否为 LOG0 到 LOG4。
+func (op OpCode) IsLog() bool {
+	return op >= LOG0 && op <= LOG4
+}
+
+// LogTopics 返回 LOG 指令携带的主题个数，其他指令返回 0。
+func (op OpCode) LogTopics() int {
+	if !op.IsLog() {
+		return 0
+	}
+	return int(op - LOG0)
+}
+
+func (op OpCode) String() string {
+	switch {
+	case op.IsPush():
+		return fmt.Sprintf("PUSH%d", op.PushSize())
+	case op >= DUP1 && op <= DUP16:
+		return fmt.Sprintf("DUP%d", op-DUP1+1)
+	case op >= SWAP1 && op <= SWAP16:
+		return fmt.Sprintf("SWAP%d", op-SWAP1+1)
+	case op.IsLog():
+		return fmt.Sprintf("LOG%d", op.LogTopics())
+	}
+	if name, ok := opCodeNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("INVALID(0x%02x)", byte(op))
+}
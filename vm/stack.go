@@ -0,0 +1,72 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// StackLimit 是栈的最大深度。
+const StackLimit = 1024
+
+// Stack 是虚拟机的操作数栈，每个元素是一个 256 位无符号整数。
+type Stack struct {
+	data []*big.Int
+}
+
+func NewStack() *Stack {
+	return &Stack{data: make([]*big.Int, 0, 16)}
+}
+
+func (s *Stack) Len() int {
+	return len(s.data)
+}
+
+// Push 将一个元素压入栈顶，超过 StackLimit 时返回错误。
+func (s *Stack) Push(v *big.Int) error {
+	if len(s.data) >= StackLimit {
+		return fmt.Errorf("stack overflow, limit %d", StackLimit)
+	}
+	s.data = append(s.data, v)
+	return nil
+}
+
+// Pop 弹出栈顶元素，栈为空时返回错误。
+func (s *Stack) Pop() (*big.Int, error) {
+	if len(s.data) == 0 {
+		return nil, fmt.Errorf("stack underflow")
+	}
+	v := s.data[len(s.data)-1]
+	s.data = s.data[:len(s.data)-1]
+	return v, nil
+}
+
+// PopN 依次弹出 n 个元素，返回值中第一个元素是原来的栈顶。
+func (s *Stack) PopN(n int) ([]*big.Int, error) {
+	if len(s.data) < n {
+		return nil, fmt.Errorf("stack underflow, need %d items, have %d", n, len(s.data))
+	}
+	values := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		values[i] = s.data[len(s.data)-1-i]
+	}
+	s.data = s.data[:len(s.data)-n]
+	return values, nil
+}
+
+// Dup 复制从栈顶开始的第 n 个元素（栈顶为第 1 个）并压入栈顶。
+func (s *Stack) Dup(n int) error {
+	if len(s.data) < n {
+		return fmt.Errorf("stack underflow, need %d items, have %d", n, len(s.data))
+	}
+	return s.Push(new(big.Int).Set(s.data[len(s.data)-n]))
+}
+
+// Swap 交换栈顶元素与其下方第 n 个元素。
+func (s *Stack) Swap(n int) error {
+	if len(s.data) <= n {
+		return fmt.Errorf("stack underflow, need %d items, have %d", n+1, len(s.data))
+	}
+	top := len(s.data) - 1
+	s.data[top], s.data[top-n] = s.data[top-n], s.data[top]
+	return nil
+}
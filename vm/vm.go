@@ -0,0 +1,406 @@
+package vm
+
+import (
+	"MyChain/types"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// WordSize 是虚拟机字长的字节数
+	WordSize = 32
+	// MemoryLimit 是单次执行可以使用的最大内存字节数
+	MemoryLimit = 1 << 20
+)
+
+var (
+	// ErrReverted 表示代码通过 REVERT 主动终止执行，Run 同时返回 REVERT 指定的数据和剩余的 gas。
+	ErrReverted = errors.New("execution reverted")
+	// ErrOutOfGas 表示执行过程中 gas 耗尽。
+	ErrOutOfGas = errors.New("out of gas")
+)
+
+var (
+	wordModulus = new(big.Int).Lsh(big.NewInt(1), 256)
+	wordMax     = new(big.Int).Sub(wordModulus, big.NewInt(1))
+)
+
+// StateDB 是虚拟机访问合约存储的接口。
+type StateDB interface {
+	GetStorage(addr types.Address, key types.Hash) types.Hash
+	SetStorage(addr types.Address, key, value types.Hash)
+}
+
+// Log 是合约通过 LOG0 到 LOG4 指令发出的事件。
+type Log struct {
+	// Address 是发出事件的合约地址
+	Address types.Address
+	// Topics 是事件的主题，最多 4 个，通常用于索引
+	Topics []types.Hash
+	// Data 是事件携带的数据
+	Data []byte
+}
+
+// Context 是代码执行的环境信息。
+type Context struct {
+	// Address 是执行代码的账户，SLOAD 和 SSTORE 访问该账户的存储
+	Address types.Address
+	// Caller 是发起调用的账户
+	Caller types.Address
+	// Value 是随调用转入的金额
+	Value uint64
+	// Input 是调用的输入数据
+	Input []byte
+	// Height 和 Timestamp 是交易所在区块的高度和时间戳
+	Height    uint32
+	Timestamp int64
+}
+
+// VM 是一个确定性的栈式虚拟机。栈元素和存储的值都是 256 位无符号整数，
+// 算术运算对 2^256 取模，除以 0 的结果为 0。每条指令都消耗 gas，gas 耗尽时执行中止。
+type VM struct {
+	ctx   Context
+	state StateDB
+	logs  []*Log
+}
+
+// New 创建一个在给定环境中执行代码的虚拟机。
+func New(ctx Context, state StateDB) *VM {
+	return &VM{
+		ctx:   ctx,
+		state: state,
+	}
+}
+
+// frame 是单次执行的运行时状态。
+type frame struct {
+	code      []byte
+	pc        uint64
+	stack     *Stack
+	memory    []byte
+	jumpdests map[uint64]bool
+	gas       uint64
+}
+
+// useGas 扣除 gas，剩余 gas 不足时返回 ErrOutOfGas。
+func (f *frame) useGas(amount uint64) error {
+	if f.gas < amount {
+		f.gas = 0
+		return ErrOutOfGas
+	}
+	f.gas -= amount
+	return nil
+}
+
+// Logs 返回最近一次 Run 发出的事件，执行出错时没有事件。
+func (vm *VM) Logs() []*Log {
+	return vm.logs
+}
+
+// Run 使用给定的 gas 执行字节码，直到遇到 STOP、RETURN、REVERT、代码结束或发生错误。
+// 执行出错时已经写入的存储不会被撤销，调用方需要自行回滚状态，但已发出的事件会被丢弃。
+//
+// 参数:
+//
+//	code - 需要执行的字节码。
+//	gas - 可用于执行的 gas。
+//
+// 返回值:
+//
+//	[]byte - RETURN 或 REVERT 返回的数据。
+//	uint64 - 剩余的 gas。除 REVERT 外，执行出错时所有 gas 都被消耗。
+//	error - 执行出错时返回错误，gas 耗尽时返回 ErrOutOfGas，代码执行 REVERT 时返回 ErrReverted。
+func (vm *VM) Run(code []byte, gas uint64) ([]byte, uint64, error) {
+	f := &frame{
+		code:      code,
+		stack:     NewStack(),
+		jumpdests: jumpDests(code),
+		gas:       gas,
+	}
+	vm.logs = nil
+	for f.pc < uint64(len(code)) {
+		op := OpCode(code[f.pc])
+		ret, halt, err := vm.step(f, op)
+		if err != nil {
+			vm.logs = nil
+		}
+		if errors.Is(err, ErrReverted) {
+			return ret, f.gas, fmt.Errorf("%s at pc %d: %w", op, f.pc, err)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s at pc %d: %w", op, f.pc, err)
+		}
+		if halt {
+			return ret, f.gas, nil
+		}
+	}
+	return nil, f.gas, nil
+}
+
+// step 执行 pc 处的一条指令，halt 为 true 表示执行正常结束。
+func (vm *VM) step(f *frame, op OpCode) ([]byte, bool, error) {
+	s := f.stack
+	next := f.pc + 1
+	if err := f.useGas(constantGas(op)); err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case op.IsPush():
+		n := uint64(op.PushSize())
+		// 代码末尾不足的立即数按 0 补齐
+		buf := make([]byte, n)
+		if next < uint64(len(f.code)) {
+			copy(buf, f.code[next:])
+		}
+		f.pc = next + n
+		return nil, false, s.Push(new(big.Int).SetBytes(buf))
+	case op >= DUP1 && op <= DUP16:
+		f.pc = next
+		return nil, false, s.Dup(int(op-DUP1) + 1)
+	case op >= SWAP1 && op <= SWAP16:
+		f.pc = next
+		return nil, false, s.Swap(int(op-SWAP1) + 1)
+	}
+
+	var (
+		args []*big.Int
+		data []byte
+		err  error
+	)
+	// 先弹出指令需要的参数，args[0] 是原来的栈顶
+	if n := popCount(op); n > 0 {
+		if args, err = s.PopN(n); err != nil {
+			return nil, false, err
+		}
+	}
+
+	switch op {
+	case STOP:
+		return nil, true, nil
+	case ADD, MUL, SUB, DIV, MOD, LT, GT, EQ, AND, OR, XOR:
+		err = s.Push(binaryOp(op, args[0], args[1]))
+	case ISZERO:
+		err = s.Push(boolWord(args[0].Sign() == 0))
+	case NOT:
+		err = s.Push(new(big.Int).Xor(args[0], wordMax))
+	case SHA256:
+		if args[1].IsUint64() {
+			err = f.useGas(toWords(args[1].Uint64()) * GasSHA256Word)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if data, err = f.read(args[0], args[1]); err == nil {
+			sum := sha256.Sum256(data)
+			err = s.Push(new(big.Int).SetBytes(sum[:]))
+		}
+	case ADDRESS:
+		err = s.Push(new(big.Int).SetBytes(vm.ctx.Address.ToSlice()))
+	case CALLER:
+		err = s.Push(new(big.Int).SetBytes(vm.ctx.Caller.ToSlice()))
+	case CALLVALUE:
+		err = s.Push(new(big.Int).SetUint64(vm.ctx.Value))
+	case CALLDATALOAD:
+		// 超出输入数据的部分按 0 补齐
+		buf := make([]byte, WordSize)
+		if args[0].IsUint64() && args[0].Uint64() < uint64(len(vm.ctx.Input)) {
+			copy(buf, vm.ctx.Input[args[0].Uint64():])
+		}
+		err = s.Push(new(big.Int).SetBytes(buf))
+	case CALLDATASIZE:
+		err = s.Push(new(big.Int).SetUint64(uint64(len(vm.ctx.Input))))
+	case TIMESTAMP:
+		err = s.Push(new(big.Int).SetUint64(uint64(vm.ctx.Timestamp)))
+	case NUMBER:
+		err = s.Push(new(big.Int).SetUint64(uint64(vm.ctx.Height)))
+	case POP, JUMPDEST:
+	case MLOAD:
+		if data, err = f.read(args[0], big.NewInt(WordSize)); err == nil {
+			err = s.Push(new(big.Int).SetBytes(data))
+		}
+	case MSTORE:
+		err = f.write(args[0], args[1].FillBytes(make([]byte, WordSize)))
+	case MSTORE8:
+		// 只写入最低的一个字节
+		err = f.write(args[0], []byte{byte(new(big.Int).And(args[1], big.NewInt(0xff)).Uint64())})
+	case SLOAD:
+		value := vm.state.GetStorage(vm.ctx.Address, wordToHash(args[0]))
+		err = s.Push(new(big.Int).SetBytes(value[:]))
+	case SSTORE:
+		key, value := wordToHash(args[0]), wordToHash(args[1])
+		// 将空存储槽设置为非零值比修改已有的值更贵
+		cost := GasSStoreReset
+		if vm.state.GetStorage(vm.ctx.Address, key).IsZero() && !value.IsZero() {
+			cost = GasSStoreSet
+		}
+		if err = f.useGas(cost); err == nil {
+			vm.state.SetStorage(vm.ctx.Address, key, value)
+		}
+	case LOG0, LOG0 + 1, LOG0 + 2, LOG0 + 3, LOG4:
+		// 超出内存上限的数据在读取时报错，这里只需避免乘法溢出
+		if args[1].IsUint64() && args[1].Uint64() <= MemoryLimit {
+			err = f.useGas(args[1].Uint64() * GasLogData)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if data, err = f.read(args[0], args[1]); err == nil {
+			log := &Log{Address: vm.ctx.Address, Data: data}
+			for _, topic := range args[2:] {
+				log.Topics = append(log.Topics, wordToHash(topic))
+			}
+			vm.logs = append(vm.logs, log)
+		}
+	case JUMP:
+		return nil, false, f.jump(args[0])
+	case JUMPI:
+		if args[1].Sign() != 0 {
+			return nil, false, f.jump(args[0])
+		}
+	case PC:
+		err = s.Push(new(big.Int).SetUint64(f.pc))
+	case MSIZE:
+		err = s.Push(new(big.Int).SetUint64(uint64(len(f.memory))))
+	case RETURN:
+		data, err = f.read(args[0], args[1])
+		return data, err == nil, err
+	case REVERT:
+		if data, err = f.read(args[0], args[1]); err != nil {
+			return nil, false, err
+		}
+		return data, false, ErrReverted
+	default:
+		return nil, false, fmt.Errorf("invalid opcode")
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	f.pc = next
+	return nil, false, nil
+}
+
+// popCount 返回指令从栈上弹出的参数个数。
+func popCount(op OpCode) int {
+	switch op {
+	case ADD, MUL, SUB, DIV, MOD, LT, GT, EQ, AND, OR, XOR,
+		SHA256, MSTORE, MSTORE8, SSTORE, JUMPI, RETURN, REVERT:
+		return 2
+	case ISZERO, NOT, CALLDATALOAD, POP, MLOAD, SLOAD, JUMP:
+		return 1
+	}
+	if op.IsLog() {
+		return 2 + op.LogTopics()
+	}
+	return 0
+}
+
+// binaryOp 计算二元运算 a op b，a 是原来的栈顶。
+func binaryOp(op OpCode, a, b *big.Int) *big.Int {
+	r := new(big.Int)
+	switch op {
+	case ADD:
+		r.Add(a, b)
+	case MUL:
+		r.Mul(a, b)
+	case SUB:
+		r.Sub(a, b)
+	case DIV:
+		if b.Sign() != 0 {
+			r.Div(a, b)
+		}
+	case MOD:
+		if b.Sign() != 0 {
+			r.Mod(a, b)
+		}
+	case LT:
+		return boolWord(a.Cmp(b) < 0)
+	case GT:
+		return boolWord(a.Cmp(b) > 0)
+	case EQ:
+		return boolWord(a.Cmp(b) == 0)
+	case AND:
+		r.And(a, b)
+	case OR:
+		r.Or(a, b)
+	case XOR:
+		r.Xor(a, b)
+	}
+	// 结果对 2^256 取模，减法的负数结果也会回绕为正数
+	return r.Mod(r, wordModulus)
+}
+
+func boolWord(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return new(big.Int)
+}
+
+func wordToHash(v *big.Int) types.Hash {
+	var h types.Hash
+	v.FillBytes(h[:])
+	return h
+}
+
+// jump 跳转到 dest，dest 必须是 JUMPDEST 指令的位置。
+func (f *frame) jump(dest *big.Int) error {
+	if !dest.IsUint64() || !f.jumpdests[dest.Uint64()] {
+		return fmt.Errorf("invalid jump destination %s", dest)
+	}
+	f.pc = dest.Uint64()
+	return nil
+}
+
+// read 读取内存 [offset, offset+size) 的数据，必要时扩展内存。
+func (f *frame) read(offset, size *big.Int) ([]byte, error) {
+	if size.Sign() == 0 {
+		return []byte{}, nil
+	}
+	if err := f.expand(offset, size); err != nil {
+		return nil, err
+	}
+	start := offset.Uint64()
+	data := make([]byte, size.Uint64())
+	copy(data, f.memory[start:])
+	return data, nil
+}
+
+// write 将数据写入从 offset 开始的内存，必要时扩展内存。
+func (f *frame) write(offset *big.Int, data []byte) error {
+	if err := f.expand(offset, big.NewInt(int64(len(data)))); err != nil {
+		return err
+	}
+	copy(f.memory[offset.Uint64():], data)
+	return nil
+}
+
+// expand 将内存扩展到能容纳 [offset, offset+size) 的最小字长整数倍，并扣除扩展内存的 gas。
+func (f *frame) expand(offset, size *big.Int) error {
+	end := new(big.Int).Add(offset, size)
+	if !end.IsUint64() || end.Uint64() > MemoryLimit {
+		return fmt.Errorf("memory limit %d exceeded", MemoryLimit)
+	}
+	if n := toWords(end.Uint64()) * WordSize; n > uint64(len(f.memory)) {
+		if err := f.useGas(memoryGas(n) - memoryGas(uint64(len(f.memory)))); err != nil {
+			return err
+		}
+		f.memory = append(f.memory, make([]byte, n-uint64(len(f.memory)))...)
+	}
+	return nil
+}
+
+// jumpDests 返回代码中所有合法的跳转目标，PUSH 的立即数中的 JUMPDEST 字节不是合法目标。
+func jumpDests(code []byte) map[uint64]bool {
+	dests := make(map[uint64]bool)
+	for pc := uint64(0); pc < uint64(len(code)); pc++ {
+		op := OpCode(code[pc])
+		if op == JUMPDEST {
+			dests[pc] = true
+		}
+		pc += uint64(op.PushSize())
+	}
+	return dests
+}
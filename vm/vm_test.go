@@ -0,0 +1,248 @@
+package vm
+
+import (
+	"MyChain/types"
+	"crypto/sha256"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+// memoryState 是测试使用的内存存储。
+type memoryState map[types.Address]map[types.Hash]types.Hash
+
+func (m memoryState) GetStorage(addr types.Address, key types.Hash) types.Hash {
+	return m[addr][key]
+}
+
+func (m memoryState) SetStorage(addr types.Address, key, value types.Hash) {
+	if m[addr] == nil {
+		m[addr] = make(map[types.Hash]types.Hash)
+	}
+	m[addr][key] = value
+}
+
+// push 返回压入 v 的 PUSH 指令，立即数使用能容纳 v 的最少字节数。
+func push(v uint64) []byte {
+	b := new(big.Int).SetUint64(v).Bytes()
+	if len(b) == 0 {
+		b = []byte{0}
+	}
+	return append([]byte{byte(PUSH1) + byte(len(b)-1)}, b...)
+}
+
+// program 将指令和 push 的结果拼接成字节码。
+func program(parts ...interface{}) []byte {
+	var code []byte
+	for _, p := range parts {
+		switch v := p.(type) {
+		case OpCode:
+			code = append(code, byte(v))
+		case []byte:
+			code = append(code, v...)
+		}
+	}
+	return code
+}
+
+// returnTop 将栈顶写入内存并返回。
+func returnTop() []byte {
+	return program(push(0), MSTORE, push(32), push(0), RETURN)
+}
+
+const testGas = 1000000
+
+func run(t *testing.T, code []byte) []byte {
+	ret, _, err := New(Context{}, memoryState{}).Run(code, testGas)
+	assert.Nil(t, err)
+	return ret
+}
+
+func word(v uint64) []byte {
+	return new(big.Int).SetUint64(v).FillBytes(make([]byte, WordSize))
+}
+
+func TestVM_Arithmetic(t *testing.T) {
+	cases := []struct {
+		code     []byte
+		expected []byte
+	}{
+		{program(push(2), push(3), ADD), word(5)},
+		{program(push(2), push(3), MUL), word(6)},
+		// 栈顶减去下一个元素
+		{program(push(2), push(10), SUB), word(8)},
+		{program(push(3), push(10), DIV), word(3)},
+		{program(push(3), push(10), MOD), word(1)},
+		{program(push(0), push(10), DIV), word(0)},
+		{program(push(2), push(1), LT), word(1)},
+		{program(push(2), push(1), GT), word(0)},
+		{program(push(7), push(7), EQ), word(1)},
+		{program(push(0), ISZERO), word(1)},
+		{program(push(0xf0), push(0x3c), AND), word(0x30)},
+		{program(push(0xf0), push(0x0f), OR), word(0xff)},
+		{program(push(0xff), push(0x0f), XOR), word(0xf0)},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, run(t, append(c.code, returnTop()...)), "%x", c.code)
+	}
+
+	// 下溢回绕
+	ret := run(t, program(push(1), push(0), SUB, returnTop()))
+	assert.Equal(t, wordMax.FillBytes(make([]byte, WordSize)), ret)
+	ret = run(t, program(push(0), NOT, push(1), ADD, returnTop()))
+	assert.Equal(t, word(0), ret)
+}
+
+func TestVM_ControlFlow(t *testing.T) {
+	// 计算 1 + 2 + ... + 10
+	code := program(
+		push(0), push(10), // sum, i
+		JUMPDEST, // pc 4
+		DUP1, ISZERO, push(21), JUMPI,
+		DUP1, SWAP1+1, ADD, SWAP1, // sum += i
+		push(1), SWAP1, SUB, // i -= 1
+		push(4), JUMP,
+		JUMPDEST, // pc 21
+		POP, returnTop(),
+	)
+	assert.Equal(t, byte(JUMPDEST), code[21])
+	assert.Equal(t, word(55), run(t, code))
+
+	// 跳转目标必须是 JUMPDEST，且不能位于 PUSH 的立即数中
+	_, _, err := New(Context{}, memoryState{}).Run(program(push(3), JUMP, push(uint64(JUMPDEST))), testGas)
+	assert.NotNil(t, err)
+	_, _, err = New(Context{}, memoryState{}).Run(program(push(4), JUMP, push(uint64(JUMPDEST))), testGas)
+	assert.NotNil(t, err)
+}
+
+func TestVM_Errors(t *testing.T) {
+	v := New(Context{}, memoryState{})
+	_, gas, err := v.Run(program(ADD), testGas)
+	assert.NotNil(t, err)
+	// 出错时消耗所有 gas
+	assert.Equal(t, uint64(0), gas)
+	_, _, err = v.Run([]byte{0xef}, testGas)
+	assert.NotNil(t, err)
+	_, _, err = v.Run(program(push(MemoryLimit), MLOAD), testGas)
+	assert.NotNil(t, err)
+
+	// REVERT 返回数据和剩余的 gas
+	ret, gas, err := v.Run(program(push(0x2a), push(0), MSTORE8, push(1), push(0), REVERT), testGas)
+	assert.True(t, errors.Is(err, ErrReverted))
+	assert.Equal(t, []byte{0x2a}, ret)
+	assert.Equal(t, testGas-5*GasFastest-memoryGas(WordSize), gas)
+}
+
+func TestVM_Gas(t *testing.T) {
+	v := New(Context{}, memoryState{})
+	_, gas, err := v.Run(program(push(1), push(2), ADD), testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, testGas-3*GasFastest, gas)
+
+	// gas 恰好足够
+	_, gas, err = v.Run(program(push(1), push(2), ADD), 3*GasFastest)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), gas)
+	_, _, err = v.Run(program(push(1), push(2), ADD), 3*GasFastest-1)
+	assert.True(t, errors.Is(err, ErrOutOfGas))
+
+	// 死循环会因 gas 耗尽而终止
+	_, gas, err = v.Run(program(JUMPDEST, push(0), JUMP), testGas)
+	assert.True(t, errors.Is(err, ErrOutOfGas))
+	assert.Equal(t, uint64(0), gas)
+
+	// 设置空存储槽比修改已有的值更贵
+	state := memoryState{}
+	sstore := program(push(1), push(0), SSTORE)
+	_, gas, err = New(Context{}, state).Run(sstore, testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, testGas-2*GasFastest-GasSStoreSet, gas)
+	_, gas, err = New(Context{}, state).Run(sstore, testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, testGas-2*GasFastest-GasSStoreReset, gas)
+
+	// 内存扩展的费用随大小超线性增长
+	assert.Equal(t, uint64(3), memoryGas(1))
+	assert.Equal(t, 1024*GasMemoryWord+1024*1024/memoryQuadCoeffDiv, memoryGas(1024*WordSize))
+}
+
+func TestVM_Storage(t *testing.T) {
+	state := memoryState{}
+	ctx := Context{Address: types.MustAddressFromBytes(types.RandomBytes(20))}
+	_, _, err := New(ctx, state).Run(program(push(42), push(1), SSTORE), testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, types.MustHashFromBytes(word(42)), state.GetStorage(ctx.Address, types.MustHashFromBytes(word(1))))
+
+	ret, _, err := New(ctx, state).Run(program(push(1), SLOAD, returnTop()), testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, word(42), ret)
+
+	// 其他账户的存储是独立的
+	ret, _, err = New(Context{}, state).Run(program(push(1), SLOAD, returnTop()), testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, word(0), ret)
+}
+
+func TestVM_Environment(t *testing.T) {
+	ctx := Context{
+		Address:   types.MustAddressFromBytes(types.RandomBytes(20)),
+		Caller:    types.MustAddressFromBytes(types.RandomBytes(20)),
+		Value:     7,
+		Input:     []byte{0x01, 0x02},
+		Height:    9,
+		Timestamp: 11,
+	}
+	get := func(op OpCode) []byte {
+		ret, _, err := New(ctx, memoryState{}).Run(program(op, returnTop()), testGas)
+		assert.Nil(t, err)
+		return ret
+	}
+	assert.Equal(t, append(make([]byte, 12), ctx.Address.ToSlice()...), get(ADDRESS))
+	assert.Equal(t, append(make([]byte, 12), ctx.Caller.ToSlice()...), get(CALLER))
+	assert.Equal(t, word(7), get(CALLVALUE))
+	assert.Equal(t, word(2), get(CALLDATASIZE))
+	assert.Equal(t, word(9), get(NUMBER))
+	assert.Equal(t, word(11), get(TIMESTAMP))
+
+	ret, _, err := New(ctx, memoryState{}).Run(program(push(1), CALLDATALOAD, returnTop()), testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, append([]byte{0x02}, make([]byte, 31)...), ret)
+}
+
+func TestVM_SHA256(t *testing.T) {
+	ret := run(t, program(push(0x616263), push(0), MSTORE, push(3), push(29), SHA256, returnTop()))
+	sum := sha256.Sum256([]byte("abc"))
+	assert.Equal(t, sum[:], ret)
+}
+
+func TestVM_Log(t *testing.T) {
+	ctx := Context{Address: types.MustAddressFromBytes(types.RandomBytes(20))}
+	v := New(ctx, memoryState{})
+	code := program(push(0x2a), push(0), MSTORE8, push(7), push(5), push(1), push(0), LOG0+2)
+	_, gas, err := v.Run(code, testGas)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Log{{
+		Address: ctx.Address,
+		Topics:  []types.Hash{types.MustHashFromBytes(word(5)), types.MustHashFromBytes(word(7))},
+		Data:    []byte{0x2a},
+	}}, v.Logs())
+	assert.Equal(t, testGas-7*GasFastest-memoryGas(WordSize)-GasLog-2*GasLogTopic-GasLogData, gas)
+
+	// 执行出错时丢弃已发出的事件
+	_, _, err = v.Run(program(push(0), push(0), LOG0, push(0), push(0), REVERT), testGas)
+	assert.True(t, errors.Is(err, ErrReverted))
+	assert.Empty(t, v.Logs())
+
+	// 栈上的参数不足
+	_, _, err = v.Run(program(push(0), push(0), LOG0+1), testGas)
+	assert.NotNil(t, err)
+}
+
+func TestOpCode_String(t *testing.T) {
+	assert.Equal(t, "ADD", ADD.String())
+	assert.Equal(t, "LOG2", (LOG0 + 2).String())
+	assert.Equal(t, "PUSH32", PUSH32.String())
+	assert.Equal(t, "DUP3", (DUP1 + 2).String())
+	assert.Equal(t, "INVALID(0xef)", OpCode(0xef).String())
+}